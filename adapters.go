@@ -0,0 +1,398 @@
+package sparseset
+
+// The iterator adapters modify the iterator in place and return the iterator
+// itself, so they can be chained, e.g., Iterate(set).Filter(f).Take(10). The
+// adapters must be applied before the iteration starts, i.e., before the first
+// call to Next() or Peek(). The adapters are lazy and they do not allocate
+// memory per element.
+
+// Filter restricts the iterator to the elements for which pred returns true.
+func (i *IteratorOf[K, A]) Filter(pred func(K, *A) bool) *IteratorOf[K, A] {
+	get, hint := i.adapt()
+	skipped := 0
+	i.get = func(index int) (K, *A, bool) {
+		for {
+			key, a, ok := get(index + skipped)
+			if !ok || pred(key, a) {
+				return key, a, ok
+			}
+			skipped++
+		}
+	}
+	i.hint = filterHint(hint)
+	return i
+}
+
+// Take restricts the iterator to its first n elements.
+func (i *IteratorOf[K, A]) Take(n int) *IteratorOf[K, A] {
+	get, hint := i.adapt()
+	taken := 0
+	i.get = func(index int) (K, *A, bool) {
+		if taken >= n {
+			return 0, nil, false
+		}
+
+		key, a, ok := get(index)
+		if ok {
+			taken++
+		}
+		return key, a, ok
+	}
+	i.hint = takeHint(hint, n, &taken)
+	return i
+}
+
+// Skip skips the first n elements of the iterator.
+func (i *IteratorOf[K, A]) Skip(n int) *IteratorOf[K, A] {
+	get, hint := i.adapt()
+	skipped := false
+	i.get = func(index int) (K, *A, bool) {
+		if !skipped {
+			skipped = true
+			for j := 0; j < n; j++ {
+				if _, _, ok := get(j); !ok {
+					return 0, nil, false
+				}
+			}
+		}
+		return get(index + n)
+	}
+	i.hint = skipHint(hint, n, &skipped)
+	return i
+}
+
+// Chain appends the elements of other to the iterator. The other iterator is
+// consumed by this iterator.
+func (i *IteratorOf[K, A]) Chain(other *IteratorOf[K, A]) *IteratorOf[K, A] {
+	get, hint := i.adapt()
+	done := false
+	i.get = func(index int) (K, *A, bool) {
+		if !done {
+			key, a, ok := get(index)
+			if ok {
+				return key, a, true
+			}
+			done = true
+		}
+		return other.Next()
+	}
+	i.hint = chainHint(hint, other.SizeHint, &done)
+	return i
+}
+
+// Filter restricts the iterator to the elements for which pred returns true.
+func (i *JoinIteratorOf[K, A, B]) Filter(pred func(K, *A, *B) bool) *JoinIteratorOf[K, A, B] {
+	get, hint := i.adapt()
+	i.get = func() (K, *A, *B, bool) {
+		for {
+			key, a, b, ok := get()
+			if !ok || pred(key, a, b) {
+				return key, a, b, ok
+			}
+		}
+	}
+	i.hint = filterHint(hint)
+	return i
+}
+
+// Take restricts the iterator to its first n elements.
+func (i *JoinIteratorOf[K, A, B]) Take(n int) *JoinIteratorOf[K, A, B] {
+	get, hint := i.adapt()
+	taken := 0
+	i.get = func() (K, *A, *B, bool) {
+		if taken >= n {
+			return 0, nil, nil, false
+		}
+
+		key, a, b, ok := get()
+		if ok {
+			taken++
+		}
+		return key, a, b, ok
+	}
+	i.hint = takeHint(hint, n, &taken)
+	return i
+}
+
+// Skip skips the first n elements of the iterator.
+func (i *JoinIteratorOf[K, A, B]) Skip(n int) *JoinIteratorOf[K, A, B] {
+	get, hint := i.adapt()
+	skipped := false
+	i.get = func() (K, *A, *B, bool) {
+		if !skipped {
+			skipped = true
+			for j := 0; j < n; j++ {
+				if _, _, _, ok := get(); !ok {
+					return 0, nil, nil, false
+				}
+			}
+		}
+		return get()
+	}
+	i.hint = skipHint(hint, n, &skipped)
+	return i
+}
+
+// Chain appends the elements of other to the iterator. The other iterator is
+// consumed by this iterator.
+func (i *JoinIteratorOf[K, A, B]) Chain(other *JoinIteratorOf[K, A, B]) *JoinIteratorOf[K, A, B] {
+	get, hint := i.adapt()
+	done := false
+	i.get = func() (K, *A, *B, bool) {
+		if !done {
+			key, a, b, ok := get()
+			if ok {
+				return key, a, b, true
+			}
+			done = true
+		}
+		return other.Next()
+	}
+	i.hint = chainHint(hint, other.SizeHint, &done)
+	return i
+}
+
+// Filter restricts the iterator to the elements for which pred returns true.
+func (i *Join3IteratorOf[K, A, B, C]) Filter(pred func(K, *A, *B, *C) bool) *Join3IteratorOf[K, A, B, C] {
+	get, hint := i.adapt()
+	i.get = func() (K, *A, *B, *C, bool) {
+		for {
+			key, a, b, c, ok := get()
+			if !ok || pred(key, a, b, c) {
+				return key, a, b, c, ok
+			}
+		}
+	}
+	i.hint = filterHint(hint)
+	return i
+}
+
+// Take restricts the iterator to its first n elements.
+func (i *Join3IteratorOf[K, A, B, C]) Take(n int) *Join3IteratorOf[K, A, B, C] {
+	get, hint := i.adapt()
+	taken := 0
+	i.get = func() (K, *A, *B, *C, bool) {
+		if taken >= n {
+			return 0, nil, nil, nil, false
+		}
+
+		key, a, b, c, ok := get()
+		if ok {
+			taken++
+		}
+		return key, a, b, c, ok
+	}
+	i.hint = takeHint(hint, n, &taken)
+	return i
+}
+
+// Skip skips the first n elements of the iterator.
+func (i *Join3IteratorOf[K, A, B, C]) Skip(n int) *Join3IteratorOf[K, A, B, C] {
+	get, hint := i.adapt()
+	skipped := false
+	i.get = func() (K, *A, *B, *C, bool) {
+		if !skipped {
+			skipped = true
+			for j := 0; j < n; j++ {
+				if _, _, _, _, ok := get(); !ok {
+					return 0, nil, nil, nil, false
+				}
+			}
+		}
+		return get()
+	}
+	i.hint = skipHint(hint, n, &skipped)
+	return i
+}
+
+// Chain appends the elements of other to the iterator. The other iterator is
+// consumed by this iterator.
+func (i *Join3IteratorOf[K, A, B, C]) Chain(other *Join3IteratorOf[K, A, B, C]) *Join3IteratorOf[K, A, B, C] {
+	get, hint := i.adapt()
+	done := false
+	i.get = func() (K, *A, *B, *C, bool) {
+		if !done {
+			key, a, b, c, ok := get()
+			if ok {
+				return key, a, b, c, true
+			}
+			done = true
+		}
+		return other.Next()
+	}
+	i.hint = chainHint(hint, other.SizeHint, &done)
+	return i
+}
+
+// Filter restricts the iterator to the elements for which pred returns true.
+func (i *Join4IteratorOf[K, A, B, C, D]) Filter(pred func(K, *A, *B, *C, *D) bool) *Join4IteratorOf[K, A, B, C, D] {
+	get, hint := i.adapt()
+	i.get = func() (K, *A, *B, *C, *D, bool) {
+		for {
+			key, a, b, c, d, ok := get()
+			if !ok || pred(key, a, b, c, d) {
+				return key, a, b, c, d, ok
+			}
+		}
+	}
+	i.hint = filterHint(hint)
+	return i
+}
+
+// Take restricts the iterator to its first n elements.
+func (i *Join4IteratorOf[K, A, B, C, D]) Take(n int) *Join4IteratorOf[K, A, B, C, D] {
+	get, hint := i.adapt()
+	taken := 0
+	i.get = func() (K, *A, *B, *C, *D, bool) {
+		if taken >= n {
+			return 0, nil, nil, nil, nil, false
+		}
+
+		key, a, b, c, d, ok := get()
+		if ok {
+			taken++
+		}
+		return key, a, b, c, d, ok
+	}
+	i.hint = takeHint(hint, n, &taken)
+	return i
+}
+
+// Skip skips the first n elements of the iterator.
+func (i *Join4IteratorOf[K, A, B, C, D]) Skip(n int) *Join4IteratorOf[K, A, B, C, D] {
+	get, hint := i.adapt()
+	skipped := false
+	i.get = func() (K, *A, *B, *C, *D, bool) {
+		if !skipped {
+			skipped = true
+			for j := 0; j < n; j++ {
+				if _, _, _, _, _, ok := get(); !ok {
+					return 0, nil, nil, nil, nil, false
+				}
+			}
+		}
+		return get()
+	}
+	i.hint = skipHint(hint, n, &skipped)
+	return i
+}
+
+// Chain appends the elements of other to the iterator. The other iterator is
+// consumed by this iterator.
+func (i *Join4IteratorOf[K, A, B, C, D]) Chain(other *Join4IteratorOf[K, A, B, C, D]) *Join4IteratorOf[K, A, B, C, D] {
+	get, hint := i.adapt()
+	done := false
+	i.get = func() (K, *A, *B, *C, *D, bool) {
+		if !done {
+			key, a, b, c, d, ok := get()
+			if ok {
+				return key, a, b, c, d, true
+			}
+			done = true
+		}
+		return other.Next()
+	}
+	i.hint = chainHint(hint, other.SizeHint, &done)
+	return i
+}
+
+// Map returns an iterator over the keys of iterator and the values returned by
+// f. The pointer to the value is only valid until the next call to Next() or
+// Peek(). The iterator is consumed by the returned iterator.
+func Map[A, B any, K Key](iterator *IteratorOf[K, A], f func(K, *A) B) *IteratorOf[K, B] {
+	var b B
+	get := func(int) (K, *B, bool) {
+		key, a, ok := iterator.Next()
+		if !ok {
+			return 0, nil, false
+		}
+
+		b = f(key, a)
+		return key, &b, true
+	}
+	return newIterator(get, iterator.SizeHint)
+}
+
+// MapJoin is like Map but for a JoinIterator.
+func MapJoin[A, B, C any, K Key](iterator *JoinIteratorOf[K, A, B], f func(K, *A, *B) C) *IteratorOf[K, C] {
+	var c C
+	get := func(int) (K, *C, bool) {
+		key, a, b, ok := iterator.Next()
+		if !ok {
+			return 0, nil, false
+		}
+
+		c = f(key, a, b)
+		return key, &c, true
+	}
+	return newIterator(get, iterator.SizeHint)
+}
+
+// MapJoin3 is like Map but for a Join3Iterator.
+func MapJoin3[A, B, C, E any, K Key](iterator *Join3IteratorOf[K, A, B, C], f func(K, *A, *B, *C) E) *IteratorOf[K, E] {
+	var e E
+	get := func(int) (K, *E, bool) {
+		key, a, b, c, ok := iterator.Next()
+		if !ok {
+			return 0, nil, false
+		}
+
+		e = f(key, a, b, c)
+		return key, &e, true
+	}
+	return newIterator(get, iterator.SizeHint)
+}
+
+// MapJoin4 is like Map but for a Join4Iterator.
+func MapJoin4[A, B, C, D, E any, K Key](iterator *Join4IteratorOf[K, A, B, C, D], f func(K, *A, *B, *C, *D) E) *IteratorOf[K, E] {
+	var e E
+	get := func(int) (K, *E, bool) {
+		key, a, b, c, d, ok := iterator.Next()
+		if !ok {
+			return 0, nil, false
+		}
+
+		e = f(key, a, b, c, d)
+		return key, &e, true
+	}
+	return newIterator(get, iterator.SizeHint)
+}
+
+// filterHint returns the size hint of a filter of an iterator with the given
+// hint.
+func filterHint(hint func() (int, int)) func() (int, int) {
+	return func() (int, int) {
+		_, upper := hint()
+		return 0, upper
+	}
+}
+
+func takeHint(hint func() (int, int), n int, taken *int) func() (int, int) {
+	return func() (int, int) {
+		lower, upper := hint()
+		remaining := max(n-*taken, 0)
+		return min(lower, remaining), min(upper, remaining)
+	}
+}
+
+func skipHint(hint func() (int, int), n int, skipped *bool) func() (int, int) {
+	return func() (int, int) {
+		lower, upper := hint()
+		if *skipped {
+			return lower, upper
+		}
+		return max(lower-n, 0), max(upper-n, 0)
+	}
+}
+
+func chainHint(hint, otherHint func() (int, int), done *bool) func() (int, int) {
+	return func() (int, int) {
+		lower, upper := otherHint()
+		if *done {
+			return lower, upper
+		}
+
+		firstLower, firstUpper := hint()
+		return firstLower + lower, firstUpper + upper
+	}
+}
@@ -0,0 +1,191 @@
+package sparseset_test
+
+import (
+	"testing"
+
+	"github.com/jabolopes/go-sparseset"
+	"golang.org/x/exp/slices"
+)
+
+func isEvenKey[A any](key int, _ *A) bool { return key%2 == 0 }
+
+func TestIterator_Adapters(t *testing.T) {
+	set := newSetFrom(0, 1, 2, 3, 4, 5, 6, 7, 8, 9)
+	other := newSetFrom(100, 101)
+
+	tests := []struct {
+		name     string
+		iterator *sparseset.Iterator[MyValue]
+		want     []int
+	}{
+		{"Filter", sparseset.Iterate(set).Filter(isEvenKey), []int{0, 2, 4, 6, 8}},
+		{"Take", sparseset.Iterate(set).Take(3), []int{0, 1, 2}},
+		{"Take beyond end", sparseset.Iterate(other).Take(3), []int{100, 101}},
+		{"Skip", sparseset.Iterate(set).Skip(7), []int{7, 8, 9}},
+		{"Skip beyond end", sparseset.Iterate(other).Skip(3), []int{}},
+		{"Chain", sparseset.Iterate(other).Chain(sparseset.Iterate(other)), []int{100, 101, 100, 101}},
+		{"Filter Skip Take", sparseset.Iterate(set).Filter(isEvenKey).Skip(1).Take(2), []int{2, 4}},
+		{"Skip Filter", sparseset.Iterate(set).Skip(5).Filter(isEvenKey), []int{6, 8}},
+		{"Chain Take", sparseset.Iterate(set).Skip(8).Chain(sparseset.Iterate(other)).Take(3), []int{8, 9, 100}},
+	}
+
+	for _, test := range tests {
+		got := []int{}
+		for _, result := range iterateAll(test.iterator) {
+			got = append(got, result.key)
+		}
+
+		if !slices.Equal(got, test.want) {
+			t.Errorf("%s: keys = %v; want %v", test.name, got, test.want)
+		}
+	}
+}
+
+func TestIterator_Peek(t *testing.T) {
+	iterator := sparseset.Iterate(newSetFrom(1, 2))
+
+	for i := 0; i < 2; i++ {
+		if key, a, ok := iterator.Peek(); key != 1 || a.value != 1 || !ok {
+			t.Errorf("Peek() = %v, %v, %v; want %v, %v, %v", key, a, ok, 1, MyValue{1}, true)
+		}
+	}
+
+	if got, want := iterateAll(iterator), []iterateResult[MyValue]{{1, MyValue{1}, true}, {2, MyValue{2}, true}}; !slices.Equal(got, want) {
+		t.Errorf("results = %v; want %v", got, want)
+	}
+
+	if key, a, ok := iterator.Peek(); key != 0 || a != nil || ok {
+		t.Errorf("Peek() = %v, %v, %v; want %v, %v, %v", key, a, ok, 0, nil, false)
+	}
+}
+
+func TestIterator_SizeHint(t *testing.T) {
+	set := newSetFrom(0, 1, 2, 3, 4, 5, 6, 7, 8, 9)
+
+	type bounds struct{ lower, upper int }
+	sizeHint := func(lower, upper int) bounds { return bounds{lower, upper} }
+
+	iterator := sparseset.Iterate(set)
+	if got, want := sizeHint(iterator.SizeHint()), (bounds{10, 10}); got != want {
+		t.Errorf("SizeHint() = %v; want %v", got, want)
+	}
+
+	iterator.Peek()
+	if got, want := sizeHint(iterator.SizeHint()), (bounds{10, 10}); got != want {
+		t.Errorf("SizeHint() after Peek() = %v; want %v", got, want)
+	}
+
+	iterator.Next()
+	iterator.Next()
+	if got, want := sizeHint(iterator.SizeHint()), (bounds{8, 8}); got != want {
+		t.Errorf("SizeHint() after Next() = %v; want %v", got, want)
+	}
+
+	tests := []struct {
+		name     string
+		iterator *sparseset.Iterator[MyValue]
+		want     bounds
+	}{
+		{"Filter", sparseset.Iterate(set).Filter(isEvenKey), bounds{0, 10}},
+		{"Take", sparseset.Iterate(set).Take(3), bounds{3, 3}},
+		{"Skip", sparseset.Iterate(set).Skip(7), bounds{3, 3}},
+		{"Chain", sparseset.Iterate(set).Chain(sparseset.Iterate(set).Filter(isEvenKey)), bounds{10, 20}},
+		{"Empty", sparseset.EmptyIterator[MyValue](), bounds{0, 0}},
+	}
+
+	for _, test := range tests {
+		if got := sizeHint(test.iterator.SizeHint()); got != test.want {
+			t.Errorf("%s: SizeHint() = %v; want %v", test.name, got, test.want)
+		}
+
+		// The hint bounds the number of remaining elements at every step.
+		hints := []bounds{sizeHint(test.iterator.SizeHint())}
+		for _, _, ok := test.iterator.Next(); ok; _, _, ok = test.iterator.Next() {
+			hints = append(hints, sizeHint(test.iterator.SizeHint()))
+		}
+
+		for n, hint := range hints {
+			if remaining := len(hints) - 1 - n; remaining < hint.lower || remaining > hint.upper {
+				t.Errorf("%s: SizeHint() = %v after %d elements; want bounds of %d", test.name, hint, n, remaining)
+			}
+		}
+	}
+}
+
+func TestMap(t *testing.T) {
+	iterator := sparseset.Map(sparseset.Iterate(newSetFrom(1, 2, 3)), func(key int, a *MyValue) string {
+		return string(rune('a' + a.value))
+	})
+
+	if got, want := iterateAll(iterator), []iterateResult[string]{{1, "b", true}, {2, "c", true}, {3, "d", true}}; !slices.Equal(got, want) {
+		t.Errorf("results = %v; want %v", got, want)
+	}
+}
+
+func TestJoinIterator_Adapters(t *testing.T) {
+	set1 := newSetFrom(0, 1, 2, 3, 4, 5, 6, 7, 8, 9)
+	set2 := newSetFrom(1, 2, 3, 4, 5, 6)
+	set3 := newSetFrom(2, 3, 4, 5, 6)
+	set4 := newSetFrom(3, 4, 5, 6)
+
+	keys := func(results []joinResult[MyValue, MyValue]) []int {
+		got := []int{}
+		for _, result := range results {
+			got = append(got, result.key)
+		}
+		return got
+	}
+
+	isEven := func(key int, _, _ *MyValue) bool { return key%2 == 0 }
+
+	join := sparseset.Join(set1, set2).Filter(isEven).Skip(1).Take(1).Chain(sparseset.Join(set2, set4))
+	if got, want := keys(joinAll(join)), []int{4, 3, 4, 5, 6}; !slices.Equal(got, want) {
+		t.Errorf("Join keys = %v; want %v", got, want)
+	}
+
+	join3 := sparseset.Join3(set1, set2, set3).Skip(2).Take(2)
+	got3 := []int{}
+	for _, result := range join3All(join3) {
+		got3 = append(got3, result.key)
+	}
+	if want := []int{4, 5}; !slices.Equal(got3, want) {
+		t.Errorf("Join3 keys = %v; want %v", got3, want)
+	}
+
+	join4 := sparseset.Join4(set1, set2, set3, set4)
+	if key, _, _, _, _, ok := join4.Peek(); key != 3 || !ok {
+		t.Errorf("Peek() = %v, %v; want %v, %v", key, ok, 3, true)
+	}
+	if lower, upper := join4.SizeHint(); lower != 1 || upper != 4 {
+		t.Errorf("SizeHint() = %d, %d; want %d, %d", lower, upper, 1, 4)
+	}
+
+	got4 := []int{}
+	for _, result := range join4All(join4.Filter(func(key int, _, _, _, _ *MyValue) bool { return key != 5 })) {
+		got4 = append(got4, result.key)
+	}
+	if want := []int{3, 4, 6}; !slices.Equal(got4, want) {
+		t.Errorf("Join4 keys = %v; want %v", got4, want)
+	}
+}
+
+func TestMapJoin(t *testing.T) {
+	set1 := newSetFrom(1, 2, 3)
+	set2 := newSetFrom(2, 3, 4)
+
+	iterator := sparseset.MapJoin(sparseset.Join(set1, set2), func(key int, a, b *MyValue) int { return a.value + b.value })
+
+	if got, want := iterateAll(iterator), []iterateResult[int]{{2, 4, true}, {3, 6, true}}; !slices.Equal(got, want) {
+		t.Errorf("results = %v; want %v", got, want)
+	}
+
+	iterator3 := sparseset.MapJoin3(sparseset.Join3(set1, set2, set1), func(key int, a, b, c *MyValue) int { return a.value * b.value * c.value })
+	if got, want := iterateAll(iterator3), []iterateResult[int]{{2, 8, true}, {3, 27, true}}; !slices.Equal(got, want) {
+		t.Errorf("results = %v; want %v", got, want)
+	}
+
+	iterator4 := sparseset.MapJoin4(sparseset.Join4(set1, set2, set1, set2), func(key int, a, b, c, d *MyValue) int { return a.value + b.value + c.value + d.value })
+	if got, want := iterateAll(iterator4), []iterateResult[int]{{2, 8, true}, {3, 12, true}}; !slices.Equal(got, want) {
+		t.Errorf("results = %v; want %v", got, want)
+	}
+}
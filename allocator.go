@@ -0,0 +1,119 @@
+package sparseset
+
+import (
+	"reflect"
+	"sync"
+)
+
+// PageAllocator allocates and releases the pages of a PagedArray. All pages of
+// an allocator have the same size.
+//
+// An allocator can be shared by several arrays, in which case it must be safe
+// to call from all the goroutines that use those arrays.
+type PageAllocator[Value any] interface {
+	// PageSize returns the number of values per page.
+	PageSize() int
+	// Allocate returns a page with PageSize() values. The contents of the page
+	// are unspecified. Returns true if the page was reused, or false if it was
+	// newly allocated.
+	Allocate() ([]Value, bool)
+	// Free releases a page that was previously returned by Allocate(). The page
+	// is not used by the caller after this.
+	Free([]Value)
+}
+
+// PoolAllocator is a PageAllocator backed by a sync.Pool. The garbage
+// collector may drain the pool at any time, in which case pages are
+// reallocated.
+//
+// This is thread-safe.
+type PoolAllocator[Value any] struct {
+	pool     sync.Pool
+	pageSize int
+}
+
+func (a *PoolAllocator[Value]) PageSize() int { return a.pageSize }
+
+func (a *PoolAllocator[Value]) Allocate() ([]Value, bool) {
+	if page, ok := a.pool.Get().([]Value); ok {
+		return page, true
+	}
+	return make([]Value, a.pageSize), false
+}
+
+func (a *PoolAllocator[Value]) Free(page []Value) { a.pool.Put(page) }
+
+// NewPoolAllocator returns a PoolAllocator. This is the allocator used by
+// NewPagedArray().
+func NewPoolAllocator[Value any](pageSize int) *PoolAllocator[Value] {
+	return &PoolAllocator[Value]{sync.Pool{}, pageSize}
+}
+
+type sharedPoolKey struct {
+	valueType reflect.Type
+	pageSize  int
+}
+
+var (
+	sharedPoolsMu sync.Mutex
+	sharedPools   = map[sharedPoolKey]any{}
+)
+
+// SharedPoolAllocator returns a PoolAllocator that is shared by all callers
+// with the same Value type and page size. This allows arrays to reuse the pages
+// released by other arrays.
+func SharedPoolAllocator[Value any](pageSize int) *PoolAllocator[Value] {
+	key := sharedPoolKey{reflect.TypeOf((*Value)(nil)).Elem(), pageSize}
+
+	sharedPoolsMu.Lock()
+	defer sharedPoolsMu.Unlock()
+
+	if allocator, ok := sharedPools[key]; ok {
+		return allocator.(*PoolAllocator[Value])
+	}
+
+	allocator := NewPoolAllocator[Value](pageSize)
+	sharedPools[key] = allocator
+	return allocator
+}
+
+// FreeListAllocator is a PageAllocator that keeps the released pages in a free
+// list. Unlike PoolAllocator, released pages are never reclaimed by the garbage
+// collector, therefore allocations are deterministic: pages are only allocated
+// when the free list is empty.
+//
+// This is thread-compatible.
+type FreeListAllocator[Value any] struct {
+	free     [][]Value
+	pageSize int
+}
+
+func (a *FreeListAllocator[Value]) PageSize() int { return a.pageSize }
+
+// Length returns the number of pages in the free list.
+func (a *FreeListAllocator[Value]) Length() int { return len(a.free) }
+
+func (a *FreeListAllocator[Value]) Allocate() ([]Value, bool) {
+	last := len(a.free) - 1
+	if last < 0 {
+		return make([]Value, a.pageSize), false
+	}
+
+	page := a.free[last]
+	a.free[last] = nil
+	a.free = a.free[:last]
+	return page, true
+}
+
+func (a *FreeListAllocator[Value]) Free(page []Value) { a.free = append(a.free, page) }
+
+// Reserve allocates pages until the free list has at least n pages.
+func (a *FreeListAllocator[Value]) Reserve(n int) {
+	for len(a.free) < n {
+		a.free = append(a.free, make([]Value, a.pageSize))
+	}
+}
+
+func NewFreeListAllocator[Value any](pageSize int) *FreeListAllocator[Value] {
+	return &FreeListAllocator[Value]{nil, pageSize}
+}
@@ -0,0 +1,132 @@
+package sparseset_test
+
+import (
+	"testing"
+
+	"github.com/jabolopes/go-sparseset"
+)
+
+func TestFreeListAllocator(t *testing.T) {
+	allocator := sparseset.NewFreeListAllocator[int](4)
+	allocator.Reserve(2)
+
+	if got := allocator.Length(); got != 2 {
+		t.Errorf("Length() = %d; want %d", got, 2)
+	}
+
+	array1 := sparseset.NewPagedArrayWithAllocator[int](allocator, 100)
+	array2 := sparseset.NewPagedArrayWithAllocator[int](allocator, 100)
+
+	// Allocates 3 pages, 2 of which come from the free list.
+	array1.Set(0, 1)
+	array1.Set(4, 2)
+	array2.Set(8, 3)
+
+	if got := allocator.Length(); got != 0 {
+		t.Errorf("Length() = %d; want %d", got, 0)
+	}
+
+	// Releases the 2 pages of array1, which are then reused by array2.
+	array1.Clear()
+
+	if got := allocator.Length(); got != 2 {
+		t.Errorf("Length() = %d; want %d", got, 2)
+	}
+
+	array2.Set(0, 4)
+	array2.Set(4, 5)
+
+	if got := allocator.Length(); got != 0 {
+		t.Errorf("Length() = %d; want %d", got, 0)
+	}
+
+	for index, want := range map[int]int{0: 4, 1: 100, 4: 5, 8: 3} {
+		if got := array2.Get(index); got != want {
+			t.Errorf("Get(%d) = %d; want %d", index, got, want)
+		}
+	}
+
+	stats := array2.Stats()
+	if stats.PoolHits != 2 || stats.PoolMisses != 1 {
+		t.Errorf("PoolHits, PoolMisses = %d, %d; want %d, %d", stats.PoolHits, stats.PoolMisses, 2, 1)
+	}
+}
+
+func TestSharedPoolAllocator(t *testing.T) {
+	if sparseset.SharedPoolAllocator[int](16) != sparseset.SharedPoolAllocator[int](16) {
+		t.Errorf("SharedPoolAllocator[int](16) returned different allocators")
+	}
+
+	if sparseset.SharedPoolAllocator[int](16) == sparseset.SharedPoolAllocator[int](32) {
+		t.Errorf("SharedPoolAllocator[int](16) and SharedPoolAllocator[int](32) returned the same allocator")
+	}
+
+	allocator := sparseset.SharedPoolAllocator[int](16)
+	if got := allocator.PageSize(); got != 16 {
+		t.Errorf("PageSize() = %d; want %d", got, 16)
+	}
+
+	if page, _ := allocator.Allocate(); len(page) != 16 {
+		t.Errorf("len(Allocate()) = %d; want %d", len(page), 16)
+	}
+}
+
+func TestNewWithOptions_PageAllocator(t *testing.T) {
+	allocator := sparseset.NewFreeListAllocator[int](8)
+	options := sparseset.Options[string]{PageAllocator: allocator}
+
+	set1 := sparseset.NewWithOptions[string](1<<10, 1<<20, options)
+	set2 := sparseset.NewWithOptions[string](1<<10, 1<<20, options)
+
+	for i := 0; i < 16; i++ {
+		*set1.Add(i) = "a"
+	}
+	for i := 0; i < 16; i++ {
+		set1.Remove(i)
+	}
+
+	if got := allocator.Length(); got != 2 {
+		t.Errorf("Length() = %d; want %d", got, 2)
+	}
+
+	*set2.Add(3) = "b"
+
+	if got := allocator.Length(); got != 1 {
+		t.Errorf("Length() = %d; want %d", got, 1)
+	}
+
+	if got, ok := set2.Get(3); !ok || *got != "b" {
+		t.Errorf("Get(3) = %v, %v; want %v, %v", got, ok, "b", true)
+	}
+}
+
+func TestPagedArray_Clear_ReleasesPages(t *testing.T) {
+	allocator := sparseset.NewFreeListAllocator[int](4)
+	options := sparseset.PagedArrayOptions[int]{Allocator: allocator, MaxLinearPages: 2}
+	array := sparseset.NewPagedArrayWithOptions(0, 100, options)
+
+	// 2 linear pages and 2 pages in the directory.
+	for _, index := range []int{0, 4, 1 << 20, 1 << 40} {
+		array.Set(index, 1)
+	}
+
+	array.Clear()
+
+	if got := allocator.Length(); got != 4 {
+		t.Errorf("Length() = %d; want %d", got, 4)
+	}
+
+	// The released pages are reused and hold no stale values.
+	array.Set(1, 2)
+	array.Set(1<<20+1, 3)
+
+	for index, want := range map[int]int{0: 100, 1: 2, 4: 100, 1 << 20: 100, 1<<20 + 1: 3, 1 << 40: 100} {
+		if got := array.Get(index); got != want {
+			t.Errorf("Get(%d) = %d; want %d", index, got, want)
+		}
+	}
+
+	if got := array.Length(); got != 2 {
+		t.Errorf("Length() = %d; want %d", got, 2)
+	}
+}
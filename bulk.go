@@ -0,0 +1,133 @@
+package sparseset
+
+import (
+	"golang.org/x/exp/slices"
+)
+
+// Reserve grows the capacity of the set, if necessary, to guarantee space for
+// another n keys. After Reserve(n), at least n keys can be added to the set
+// without reallocating the keys and values.
+func (s *SetOf[K, Value]) Reserve(n int) {
+	if n <= 0 {
+		return
+	}
+
+	s.dense = slices.Grow(s.dense, n)
+	s.store = slices.Grow(s.store, n)
+}
+
+// AddMany inserts the keys into the set and sets their values. If a key is
+// already in the set, its value is overwritten. If values is nil, the values
+// of the inserted keys are default initialized and the values of the existing
+// keys are not modified, otherwise values must have the same length as keys.
+//
+// Returns the number of keys that were inserted, i.e., that were not already in
+// the set, and the keys that were rejected because they are out of range.
+func (s *SetOf[K, Value]) AddMany(keys []K, values []Value) (int, []K) {
+	s.Reserve(len(keys))
+
+	inserted := 0
+	var rejected []K
+	for i, key := range keys {
+		pos, added, ok := s.add(key)
+		if !ok {
+			rejected = append(rejected, key)
+			continue
+		}
+
+		if added {
+			var value Value
+			s.store = append(s.store, value)
+			inserted++
+		}
+
+		if values != nil {
+			s.store[pos] = values[i]
+		}
+	}
+
+	return inserted, rejected
+}
+
+// KeyRange is the interval of keys [Lo, Hi).
+type KeyRange[K Key] struct {
+	Lo, Hi K
+}
+
+// AddRange inserts the keys in the interval [lo, hi) into the set. The keys
+// that are not already in the set are added to the index in runs of
+// consecutive keys, which fills whole pages of the index at once. If init is
+// not nil, it is called with each inserted key and its value after all keys
+// have been inserted.
+//
+// Returns the number of keys that were inserted, i.e., that were not already in
+// the set, and the intervals of keys that were rejected because they are out of
+// range, i.e., the negative keys and the keys not less than the null key of the
+// set, if any.
+func (s *SetOf[K, Value]) AddRange(lo, hi K, init func(K, *Value)) (int, []KeyRange[K]) {
+	if lo >= hi {
+		return 0, nil
+	}
+
+	var rejected []KeyRange[K]
+	if lo < 0 {
+		rejected = append(rejected, KeyRange[K]{lo, min(hi, 0)})
+		lo = 0
+	}
+
+	if !s.growable && hi > s.nullKey {
+		rejected = append(rejected, KeyRange[K]{max(lo, s.nullKey), hi})
+		hi = s.nullKey
+	}
+
+	if lo >= hi {
+		return 0, rejected
+	}
+
+	s.Reserve(int(uint64(hi - lo)))
+
+	start := len(s.dense)
+
+	// Run of consecutive keys that are not in the set and that are added to the
+	// index at once.
+	var runKey K
+	runPos := 0
+	runLen := 0
+	flush := func() {
+		s.index.SetRun(runKey, runPos, runLen)
+		runLen = 0
+	}
+
+	for key := lo; key < hi; key++ {
+		if s.Has(key) {
+			flush()
+			continue
+		}
+
+		if runLen == 0 {
+			runKey, runPos = key, len(s.dense)
+		}
+		runLen++
+
+		var value Value
+		s.dense = append(s.dense, key)
+		s.store = append(s.store, value)
+	}
+	flush()
+
+	if start > 0 && start < len(s.dense) && s.dense[start] < s.dense[start-1] {
+		s.sortedByKey = false
+	}
+
+	for _, key := range s.dense[start:] {
+		s.notifyAdded(key)
+	}
+
+	if init != nil {
+		for pos := start; pos < len(s.dense); pos++ {
+			init(s.dense[pos], &s.store[pos])
+		}
+	}
+
+	return len(s.dense) - start, rejected
+}
@@ -0,0 +1,179 @@
+package sparseset_test
+
+import (
+	"math"
+	"testing"
+
+	"github.com/jabolopes/go-sparseset"
+	"golang.org/x/exp/slices"
+)
+
+func TestReserve(t *testing.T) {
+	set := sparseset.New[MyValue](1<<10, 1<<20)
+	set.Add(0)
+	set.Reserve(100)
+
+	if got := cap(set.Values()); got < 101 {
+		t.Errorf("cap(Values()) = %d; want >= %d", got, 101)
+	}
+
+	values := set.Values()
+	for i := 1; i < 101; i++ {
+		set.Add(i)
+	}
+
+	if got := &set.Values()[0]; got != &values[0] {
+		t.Errorf("Values() was reallocated after Reserve()")
+	}
+}
+
+func TestAddMany(t *testing.T) {
+	const nullKey = 1 << 20
+	set := sparseset.New[MyValue](1<<10, nullKey)
+	*set.Add(2) = MyValue{-1}
+
+	keys := []int{1, 2, -1, 3, nullKey, 1}
+	values := []MyValue{{1}, {2}, {0}, {3}, {0}, {10}}
+
+	inserted, rejected := set.AddMany(keys, values)
+
+	if inserted != 2 {
+		t.Errorf("AddMany() inserted = %d; want %d", inserted, 2)
+	}
+
+	if want := []int{-1, nullKey}; !slices.Equal(rejected, want) {
+		t.Errorf("AddMany() rejected = %v; want %v", rejected, want)
+	}
+
+	for key, want := range map[int]MyValue{1: {10}, 2: {2}, 3: {3}} {
+		if got, ok := set.Get(key); !ok || *got != want {
+			t.Errorf("Get(%d) = %v, %v; want %v, %v", key, got, ok, want, true)
+		}
+	}
+
+	if got := set.Length(); got != 3 {
+		t.Errorf("Length() = %d; want %d", got, 3)
+	}
+}
+
+func TestAddMany_NilValues(t *testing.T) {
+	set := sparseset.New[MyValue](1<<10, 1<<20)
+	*set.Add(2) = MyValue{2}
+
+	if inserted, rejected := set.AddMany([]int{1, 2}, nil); inserted != 1 || rejected != nil {
+		t.Errorf("AddMany() = %d, %v; want %d, %v", inserted, rejected, 1, nil)
+	}
+
+	if got, _ := set.Get(2); *got != (MyValue{2}) {
+		t.Errorf("Get(2) = %v; want %v", *got, MyValue{2})
+	}
+}
+
+func TestAddRange(t *testing.T) {
+	const pageSize = 16
+	const nullKey = 100
+	set := sparseset.New[MyValue](pageSize, nullKey)
+	*set.Add(20) = MyValue{-1}
+	*set.Add(40) = MyValue{-1}
+
+	initialized := []int{}
+	inserted, rejected := set.AddRange(-2, nullKey+2, func(key int, value *MyValue) {
+		if got, ok := set.Get(key); got != value || !ok {
+			t.Errorf("Get(%d) = %p, %v; want %p, %v", key, got, ok, value, true)
+		}
+
+		value.value = key
+		initialized = append(initialized, key)
+	})
+
+	if inserted != nullKey-2 {
+		t.Errorf("AddRange() inserted = %d; want %d", inserted, nullKey-2)
+	}
+
+	if want := []sparseset.KeyRange[int]{{-2, 0}, {nullKey, nullKey + 2}}; !slices.Equal(rejected, want) {
+		t.Errorf("AddRange() rejected = %v; want %v", rejected, want)
+	}
+
+	if len(initialized) != nullKey-2 || slices.Contains(initialized, 20) || slices.Contains(initialized, 40) {
+		t.Errorf("init() called with %v; want all keys except 20 and 40", initialized)
+	}
+
+	if got := set.Length(); got != nullKey {
+		t.Errorf("Length() = %d; want %d", got, nullKey)
+	}
+
+	for key := 0; key < nullKey; key++ {
+		want := MyValue{key}
+		if key == 20 || key == 40 {
+			want = MyValue{-1}
+		}
+
+		if got, ok := set.Get(key); !ok || *got != want {
+			t.Errorf("Get(%d) = %v, %v; want %v, %v", key, got, ok, want, true)
+		}
+	}
+
+	// The set is still consistent after removals.
+	for key := 0; key < nullKey; key += 3 {
+		set.Remove(key)
+	}
+
+	for key := 0; key < nullKey; key++ {
+		if _, ok := set.Get(key); ok != (key%3 != 0) {
+			t.Errorf("Get(%d) = _, %v; want _, %v", key, ok, key%3 != 0)
+		}
+	}
+}
+
+func TestAddRange_OutOfRange(t *testing.T) {
+	const nullKey = 1024
+	set := sparseset.New[MyValue](16, nullKey)
+
+	tests := []struct {
+		lo, hi   int
+		inserted int
+		rejected []sparseset.KeyRange[int]
+	}{
+		{0, math.MaxInt, nullKey, []sparseset.KeyRange[int]{{nullKey, math.MaxInt}}},
+		{math.MinInt, -1, 0, []sparseset.KeyRange[int]{{math.MinInt, -1}}},
+		{math.MinInt, math.MaxInt, 0, []sparseset.KeyRange[int]{{math.MinInt, 0}, {nullKey, math.MaxInt}}},
+		{nullKey + 1, 1 << 40, 0, []sparseset.KeyRange[int]{{nullKey + 1, 1 << 40}}},
+		{10, 10, 0, nil},
+	}
+
+	for _, test := range tests {
+		inserted, rejected := set.AddRange(test.lo, test.hi, nil)
+		if inserted != test.inserted || !slices.Equal(rejected, test.rejected) {
+			t.Errorf("AddRange(%d, %d) = %d, %v; want %d, %v", test.lo, test.hi, inserted, rejected, test.inserted, test.rejected)
+		}
+	}
+
+	if got := set.Length(); got != nullKey {
+		t.Errorf("Length() = %d; want %d", got, nullKey)
+	}
+}
+
+func TestAddRange_IndexWidth(t *testing.T) {
+	const n = 1 << 17
+
+	options := sparseset.Options[MyValue]{IndexWidth: sparseset.IndexWidth16}
+	set := sparseset.NewWithOptions[MyValue](1<<10, 1<<20, options)
+
+	if inserted, _ := set.AddRange(0, n, nil); inserted != n {
+		t.Errorf("AddRange() inserted = %d; want %d", inserted, n)
+	}
+
+	if got := set.IndexWidth(); got != sparseset.IndexWidth32 {
+		t.Errorf("IndexWidth() = %v; want %v", got, sparseset.IndexWidth32)
+	}
+
+	for key := 0; key < n; key += 997 {
+		if got := set.Keys()[key]; got != key {
+			t.Errorf("Keys()[%d] = %d; want %d", key, got, key)
+		}
+
+		if _, ok := set.Get(key); !ok {
+			t.Errorf("Get(%d) = _, %v; want _, %v", key, ok, true)
+		}
+	}
+}
@@ -0,0 +1,103 @@
+package sparseset
+
+// ChunkIterator is a ChunkIteratorOf with int keys.
+type ChunkIterator[A any] = ChunkIteratorOf[int, A]
+
+// ChunkIteratorOf traverses the keys and values of a Set in chunks, i.e.,
+// subslices of the keys and values of the set, so that loops can operate on
+// slices directly. The values can be modified through the chunks but the keys
+// must not be modified.
+//
+// Like Iterator, this is a plain struct that does not allocate memory, the zero
+// value is an empty iterator, and an iterator can be reused with Reset().
+type ChunkIteratorOf[K Key, A any] struct {
+	dense []K
+	store []A
+	// Maximum number of elements per chunk.
+	size int
+	// Position of the first element of the next chunk.
+	pos int
+}
+
+// Next returns the keys and values of the next chunk. All chunks have size
+// elements except the last chunk, which can have fewer. If the boolean is
+// false, then the end of the iteration has been reached.
+func (i *ChunkIteratorOf[K, A]) Next() ([]K, []A, bool) {
+	if i.pos >= len(i.dense) {
+		return nil, nil, false
+	}
+
+	from, to := i.pos, min(i.pos+i.size, len(i.dense))
+	i.pos = to
+	return i.dense[from:to:to], i.store[from:to:to], true
+}
+
+// Reset restarts the iterator over the chunks of the set (see Chunks()).
+func (i *ChunkIteratorOf[K, A]) Reset(set *SetOf[K, A], size int) {
+	*i = ChunkIteratorOf[K, A]{set.dense, set.store, max(size, 1), 0 /* pos */}
+}
+
+// Chunks returns an iterator that traverses the keys and values of the set in
+// chunks of (at most) size elements.
+func Chunks[A any, K Key](set *SetOf[K, A], size int) *ChunkIteratorOf[K, A] {
+	return &ChunkIteratorOf[K, A]{set.dense, set.store, max(size, 1), 0 /* pos */}
+}
+
+// JoinChunkIterator is a JoinChunkIteratorOf with int keys.
+type JoinChunkIterator[A, B any] = JoinChunkIteratorOf[int, A, B]
+
+// JoinChunkIteratorOf traverses the join of 2 sets in chunks (see Join()). The
+// keys in the join are not contiguous in the sets, therefore the keys and the
+// pointers to the values of each chunk are gathered into buffers, which are
+// reused by all the chunks.
+//
+// The zero value is an empty iterator and an iterator can be reused with
+// Reset(), which also reuses the buffers.
+type JoinChunkIteratorOf[K Key, A, B any] struct {
+	join JoinIteratorOf[K, A, B]
+	// Maximum number of elements per chunk.
+	size int
+	// Buffers of the chunks.
+	keys []K
+	as   []*A
+	bs   []*B
+}
+
+// Next returns the keys and pointers to values of the next chunk. The slices
+// are only valid until the next call to Next() or Reset(). All chunks have
+// size elements except the last chunk, which can have fewer. If the boolean is
+// false, then the end of the iteration has been reached.
+func (i *JoinChunkIteratorOf[K, A, B]) Next() ([]K, []*A, []*B, bool) {
+	i.keys, i.as, i.bs = i.keys[:0], i.as[:0], i.bs[:0]
+
+	for len(i.keys) < i.size {
+		key, a, b, ok := i.join.Next()
+		if !ok {
+			break
+		}
+
+		i.keys = append(i.keys, key)
+		i.as = append(i.as, a)
+		i.bs = append(i.bs, b)
+	}
+
+	if len(i.keys) == 0 {
+		return nil, nil, nil, false
+	}
+	return i.keys, i.as, i.bs, true
+}
+
+// Reset restarts the iterator over the chunks of the join of the given sets
+// (see JoinChunks()). The buffers are reused.
+func (i *JoinChunkIteratorOf[K, A, B]) Reset(set1 *SetOf[K, A], set2 *SetOf[K, B], size int) {
+	i.join.Reset(set1, set2)
+	i.size = max(size, 1)
+}
+
+// JoinChunks returns an iterator that traverses the join of the sets in chunks
+// of (at most) size elements.
+func JoinChunks[A, B any, K Key](set1 *SetOf[K, A], set2 *SetOf[K, B], size int) *JoinChunkIteratorOf[K, A, B] {
+	iterator := &JoinChunkIteratorOf[K, A, B]{}
+	iterator.Reset(set1, set2, size)
+	return iterator
+}
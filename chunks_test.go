@@ -0,0 +1,104 @@
+package sparseset_test
+
+import (
+	"testing"
+
+	"github.com/jabolopes/go-sparseset"
+	"golang.org/x/exp/slices"
+)
+
+func TestChunks(t *testing.T) {
+	set := newBenchmarkSet(7, 10)
+
+	gotKeys := [][]int{}
+	for iterator := sparseset.Chunks(set, 3); ; {
+		keys, values, ok := iterator.Next()
+		if !ok {
+			break
+		}
+
+		if len(keys) != len(values) {
+			t.Fatalf("Next() = %v, %v; want slices of the same length", keys, values)
+		}
+
+		for i := range values {
+			values[i] *= 2
+		}
+
+		gotKeys = append(gotKeys, keys)
+	}
+
+	wantKeys := [][]int{{0, 10, 20}, {30, 40, 50}, {60}}
+	if !slices.EqualFunc(gotKeys, wantKeys, slices.Equal[[]int]) {
+		t.Errorf("keys = %v; want %v", gotKeys, wantKeys)
+	}
+
+	if got, want := set.Values(), []int{0, 2, 4, 6, 8, 10, 12}; !slices.Equal(got, want) {
+		t.Errorf("Values() = %v; want %v", got, want)
+	}
+}
+
+func TestChunks_EmptySet(t *testing.T) {
+	set := sparseset.New[int](4096, 1<<20)
+
+	if keys, values, ok := sparseset.Chunks(set, 3).Next(); keys != nil || values != nil || ok {
+		t.Errorf("Next() = %v, %v, %v; want %v, %v, %v", keys, values, ok, nil, nil, false)
+	}
+
+	var iterator sparseset.ChunkIterator[int]
+	if _, _, ok := iterator.Next(); ok {
+		t.Errorf("Next() on zero value = _, _, %v; want _, _, %v", ok, false)
+	}
+}
+
+func TestJoinChunks(t *testing.T) {
+	set1 := newBenchmarkSet(10, 1)
+	set2 := newBenchmarkSet(10, 2)
+
+	iterator := sparseset.JoinChunks(set1, set2, 2)
+	for reset := 0; reset < 2; reset++ {
+		gotKeys := [][]int{}
+		for {
+			keys, as, bs, ok := iterator.Next()
+			if !ok {
+				break
+			}
+
+			for i, key := range keys {
+				if *as[i] != key || *bs[i] != key/2 {
+					t.Errorf("values of %d = %d, %d; want %d, %d", key, *as[i], *bs[i], key, key/2)
+				}
+			}
+
+			gotKeys = append(gotKeys, slices.Clone(keys))
+		}
+
+		wantKeys := [][]int{{0, 2}, {4, 6}, {8}}
+		if !slices.EqualFunc(gotKeys, wantKeys, slices.Equal[[]int]) {
+			t.Errorf("keys = %v; want %v", gotKeys, wantKeys)
+		}
+
+		iterator.Reset(set1, set2, 2)
+	}
+}
+
+func BenchmarkChunks(b *testing.B) {
+	set := newBenchmarkSet(10000, 1)
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	sum := 0
+	var iterator sparseset.ChunkIterator[int]
+	for n := 0; n < b.N; n++ {
+		for iterator.Reset(set, 256); ; {
+			_, values, ok := iterator.Next()
+			if !ok {
+				break
+			}
+
+			for _, value := range values {
+				sum += value
+			}
+		}
+	}
+}
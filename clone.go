@@ -0,0 +1,31 @@
+package sparseset
+
+import (
+	"golang.org/x/exp/slices"
+)
+
+// Clone returns an independent copy of the set with the same keys, values,
+// order and options. The pages of the index are copied page by page and they
+// are allocated by the same allocator as the pages of the set.
+//
+// The values are copied with cloneValue, or by assignment (i.e., a shallow
+// copy) if cloneValue is nil, which is enough for value types without
+// pointers.
+func Clone[Value any, K Key](set *SetOf[K, Value], cloneValue func(*Value) Value) *SetOf[K, Value] {
+	var store []Value
+	if cloneValue == nil {
+		store = slices.Clone(set.store)
+	} else {
+		store = make([]Value, len(set.store), cap(set.store))
+		for i := range set.store {
+			store[i] = cloneValue(&set.store[i])
+		}
+	}
+
+	return &SetOf[K, Value]{
+		set.sparse.clone(),
+		store,
+		set.destroyValue,
+		set.shrinkThreshold,
+	}
+}
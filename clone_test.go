@@ -0,0 +1,98 @@
+package sparseset_test
+
+import (
+	"testing"
+
+	"github.com/jabolopes/go-sparseset"
+	"golang.org/x/exp/slices"
+)
+
+func TestClone(t *testing.T) {
+	destroyed := 0
+	options := sparseset.Options[MyValue]{
+		DestroyValue: func(*MyValue) { destroyed++ },
+		IndexWidth:   sparseset.IndexWidth16,
+	}
+	set := sparseset.NewWithOptions[MyValue](1<<4, 1<<20, options)
+
+	for _, key := range []int{7, 100, 3, 42, 1000} {
+		set.Add(key).value = key
+	}
+	set.Remove(3)
+
+	clone := sparseset.Clone(set, nil)
+
+	if got, want := clone.Keys(), set.Keys(); !slices.Equal(got, want) {
+		t.Errorf("Keys() = %v; want %v", got, want)
+	}
+
+	if got, want := clone.Values(), set.Values(); !slices.Equal(got, want) {
+		t.Errorf("Values() = %v; want %v", got, want)
+	}
+
+	if got, want := clone.IndexWidth(), set.IndexWidth(); got != want {
+		t.Errorf("IndexWidth() = %v; want %v", got, want)
+	}
+
+	// The clone is independent of the set.
+	clone.Add(5).value = 5
+	clone.Remove(100)
+	if got, ok := clone.Get(42); ok {
+		got.value = -1
+	}
+
+	if got, want := set.Keys(), []int{7, 100, 1000, 42}; !slices.Equal(got, want) {
+		t.Errorf("Keys() = %v; want %v", got, want)
+	}
+
+	if got, ok := set.Get(42); !ok || got.value != 42 {
+		t.Errorf("Get(42) = %v, %v; want %v, %v", got, ok, MyValue{42}, true)
+	}
+
+	if set.Has(5) {
+		t.Errorf("Has(5) = %v; want %v", true, false)
+	}
+
+	// The clone preserves the options.
+	if destroyed != 2 {
+		t.Errorf("destroyed = %d; want %d", destroyed, 2)
+	}
+}
+
+func TestClone_CloneValue(t *testing.T) {
+	set := sparseset.New[[]int](1<<10, 1<<20)
+	*set.Add(1) = []int{1}
+	*set.Add(2) = []int{2, 2}
+
+	clone := sparseset.Clone(set, func(value *[]int) []int { return slices.Clone(*value) })
+
+	got, _ := clone.Get(2)
+	(*got)[0] = -1
+
+	if got, _ := set.Get(2); !slices.Equal(*got, []int{2, 2}) {
+		t.Errorf("Get(2) = %v; want %v", *got, []int{2, 2})
+	}
+}
+
+func TestClone_Growable(t *testing.T) {
+	options := sparseset.Options[MyValue]{Growable: true, MaxLinearPages: 1}
+	set := sparseset.NewWithOptions[MyValue](1<<4, 0, options)
+
+	keys := []int{0, 1 << 20, 1 << 40, 1<<40 + 1}
+	for _, key := range keys {
+		set.Add(key).value = key
+	}
+
+	clone := sparseset.Clone(set, nil)
+	set.Remove(1 << 40)
+
+	for _, key := range keys {
+		if got, ok := clone.Get(key); !ok || got.value != key {
+			t.Errorf("Get(%d) = %v, %v; want %v, %v", key, got, ok, MyValue{key}, true)
+		}
+	}
+
+	if got, want := clone.Stats().Index, set.Stats().Index; got.DirectoryNodes < want.DirectoryNodes {
+		t.Errorf("Stats().Index.DirectoryNodes = %d; want >= %d", got.DirectoryNodes, want.DirectoryNodes)
+	}
+}
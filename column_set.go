@@ -0,0 +1,116 @@
+package sparseset
+
+// column is the type-erased interface of a Column that allows a ColumnSet to
+// keep all of its columns aligned with its keys.
+type column interface {
+	grow()
+	remove(pos, last int)
+}
+
+// Column stores the values of one field of a ColumnSet contiguously by
+// position. The values of a Column are aligned with the keys of its ColumnSet,
+// i.e., Values()[pos] is the value of the key ColumnSet.Keys()[pos].
+//
+// This is thread-compatible (see thread-safety notes on Set).
+type Column[Value any] struct {
+	set    *ColumnSet
+	values []Value
+}
+
+// Values returns the values of the column by position.
+func (c *Column[Value]) Values() []Value { return c.values }
+
+func (c *Column[Value]) Get(key int) (*Value, bool) {
+	pos, ok := c.set.position(key)
+	if !ok {
+		return nil, false
+	}
+
+	return &c.values[pos], true
+}
+
+func (c *Column[Value]) grow() {
+	var value Value
+	c.values = append(c.values, value)
+}
+
+func (c *Column[Value]) remove(pos, last int) {
+	// Facilitate GC.
+	var defaultValue Value
+	c.values[pos], c.values[last] = c.values[last], defaultValue
+
+	c.values = c.values[:last]
+}
+
+// ColumnSet is a sparse set that stores values in a structure-of-arrays
+// layout. Instead of storing a struct per key, each field is stored in its own
+// Column, which shares the key bookkeeping of the set. Traversing only some of
+// the fields of the values therefore only touches the memory of those fields.
+//
+//	set := sparseset.NewColumnSet(4096, 1<<20)
+//	xs := sparseset.AddColumn[float64](set)
+//	ys := sparseset.AddColumn[float64](set)
+//
+//	pos, _ := set.Add(id)
+//	xs.Values()[pos], ys.Values()[pos] = x, y
+//
+// This is thread-compatible (see thread-safety notes on Set).
+type ColumnSet struct {
+	// Stores positions (pos) by key and keys by position.
+	sparse[int]
+	// Columns that store values by position.
+	columns []column
+}
+
+// Add inserts key into the set and returns its position in the columns. If the
+// key is already in the set, it returns its existing position. Returns false
+// if the key is out of range.
+func (s *ColumnSet) Add(key int) (int, bool) {
+	pos, added, ok := s.add(key)
+	if !ok {
+		return 0, false
+	}
+
+	if added {
+		for _, column := range s.columns {
+			column.grow()
+		}
+	}
+
+	return pos, true
+}
+
+func (s *ColumnSet) Remove(key int) {
+	pos, last, ok := s.remove(key)
+	if !ok {
+		return
+	}
+
+	for _, column := range s.columns {
+		column.remove(pos, last)
+	}
+}
+
+// Position returns the position of the key in the columns.
+func (s *ColumnSet) Position(key int) (int, bool) { return s.position(key) }
+
+// AddColumn adds a new column to the set. If the set is not empty, the column
+// is initialized with default values for all keys.
+func AddColumn[Value any](set *ColumnSet) *Column[Value] {
+	column := &Column[Value]{set, make([]Value, len(set.dense))}
+	set.columns = append(set.columns, column)
+	return column
+}
+
+// IterateColumn returns an iterator that can be used to traverse all the keys
+// of the column's set and the values of the column.
+func IterateColumn[A any](column *Column[A]) *Iterator[A] {
+	return &Iterator[A]{dense: column.set.dense, store: column.values}
+}
+
+func NewColumnSet(defaultPageSize, nullKey int) *ColumnSet {
+	return &ColumnSet{
+		newSparse(newPositionIndex[int](defaultPageSize, nullPosition(nullKey), IndexWidthInt, PagedArrayOptions[int]{}), nullKey),
+		nil, /* columns */
+	}
+}
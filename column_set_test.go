@@ -0,0 +1,110 @@
+package sparseset_test
+
+import (
+	"cmp"
+	"testing"
+
+	"github.com/jabolopes/go-sparseset"
+	"golang.org/x/exp/slices"
+)
+
+func TestColumnSet(t *testing.T) {
+	const n = 100
+
+	set := sparseset.NewColumnSet(1<<10, 1<<20)
+	xs := sparseset.AddColumn[int](set)
+	names := sparseset.AddColumn[string](set)
+
+	for i := 0; i < n; i++ {
+		pos, ok := set.Add(i)
+		if !ok {
+			t.Fatalf("Add(%d) = %d, %v; want _, %v", i, pos, ok, true)
+		}
+
+		xs.Values()[pos] = i
+		names.Values()[pos] = string(rune('a' + i%26))
+	}
+
+	for i := 0; i < n; i += 3 {
+		set.Remove(i)
+	}
+
+	if got, want := set.Length(), n-(n+2)/3; got != want {
+		t.Errorf("Length() = %d; want %d", got, want)
+	}
+
+	if got, want := len(xs.Values()), set.Length(); got != want {
+		t.Errorf("len(Values()) = %d; want %d", got, want)
+	}
+
+	for pos, key := range set.Keys() {
+		if got := xs.Values()[pos]; got != key {
+			t.Errorf("xs.Values()[%d] = %d; want %d", pos, got, key)
+		}
+
+		if got, want := names.Values()[pos], string(rune('a'+key%26)); got != want {
+			t.Errorf("names.Values()[%d] = %q; want %q", pos, got, want)
+		}
+	}
+
+	for i := 0; i < n; i++ {
+		_, ok := xs.Get(i)
+		if want := i%3 != 0; ok != want || set.Has(i) != want {
+			t.Errorf("Get(%d) = _, %v; Has(%d) = %v; want %v", i, ok, i, set.Has(i), want)
+		}
+	}
+}
+
+func TestColumnSet_AddExistingKey(t *testing.T) {
+	set := sparseset.NewColumnSet(1<<10, 1<<20)
+	xs := sparseset.AddColumn[int](set)
+
+	pos, _ := set.Add(10)
+	xs.Values()[pos] = 1
+
+	if got, ok := set.Add(10); got != pos || !ok {
+		t.Errorf("Add(10) = %d, %v; want %d, %v", got, ok, pos, true)
+	}
+
+	if got, ok := xs.Get(10); *got != 1 || !ok {
+		t.Errorf("Get(10) = %d, %v; want %d, %v", *got, ok, 1, true)
+	}
+
+	if _, ok := set.Add(-1); ok {
+		t.Errorf("Add(-1) = _, %v; want _, %v", ok, false)
+	}
+}
+
+func TestAddColumn_NonEmptySet(t *testing.T) {
+	set := sparseset.NewColumnSet(1<<10, 1<<20)
+	set.Add(1)
+	set.Add(2)
+
+	ys := sparseset.AddColumn[float64](set)
+	if got := ys.Values(); !slices.Equal(got, []float64{0, 0}) {
+		t.Errorf("Values() = %v; want %v", got, []float64{0, 0})
+	}
+}
+
+func TestIterateColumn(t *testing.T) {
+	set := sparseset.NewColumnSet(1<<10, 1<<20)
+	xs := sparseset.AddColumn[int](set)
+	_ = sparseset.AddColumn[string](set)
+
+	want := []iterateResult[int]{}
+	for i := 0; i < 10; i++ {
+		pos, _ := set.Add(i)
+		xs.Values()[pos] = i * i
+		want = append(want, iterateResult[int]{i, i * i, true})
+	}
+
+	set.Remove(4)
+	want = slices.Delete(want, 4, 5)
+
+	got := iterateAll(sparseset.IterateColumn(xs))
+	slices.SortFunc(got, func(r1, r2 iterateResult[int]) int { return cmp.Compare(r1.key, r2.key) })
+
+	if !slices.Equal(got, want) {
+		t.Errorf("results = %v; want %v", got, want)
+	}
+}
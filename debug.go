@@ -0,0 +1,8 @@
+//go:build sparsesetdebug
+
+package sparseset
+
+// debug is true in debug builds, i.e., when building with the sparsesetdebug
+// build tag. In debug builds, the methods that silently ignore invalid keys
+// (e.g., Set.Add()) panic instead.
+const debug = true
@@ -0,0 +1,48 @@
+//go:build sparsesetdebug
+
+package sparseset_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/jabolopes/go-sparseset"
+)
+
+// debugBuild is true if the tests are built with the sparsesetdebug build tag,
+// in which case the methods panic on invalid keys and values.
+const debugBuild = true
+
+func wantPanic(t *testing.T, name string, want error, f func()) {
+	t.Helper()
+
+	defer func() {
+		t.Helper()
+
+		err, ok := recover().(error)
+		if !ok || !errors.Is(err, want) {
+			t.Errorf("%s panicked with %v; want %v", name, err, want)
+		}
+	}()
+
+	f()
+}
+
+func TestDebug_Panics(t *testing.T) {
+	set := sparseset.New[MyValue](1<<10, 1<<20)
+	array := sparseset.NewPagedArray(1<<10, 1<<20)
+
+	wantPanic(t, "Add(-1)", sparseset.ErrKeyOutOfRange, func() { set.Add(-1) })
+	wantPanic(t, "Remove(1<<20)", sparseset.ErrKeyOutOfRange, func() { set.Remove(1 << 20) })
+	wantPanic(t, "Set(-1, 0)", sparseset.ErrKeyOutOfRange, func() { array.Set(-1, 0) })
+	wantPanic(t, "Set(0, 1<<20)", sparseset.ErrValueOutOfRange, func() { array.Set(0, 1<<20) })
+}
+
+func TestDebug_StableSetPanics(t *testing.T) {
+	set := sparseset.NewStable[MyValue](1<<10, 1<<20, 4)
+
+	wantPanic(t, "Add(-1)", sparseset.ErrKeyOutOfRange, func() { set.Add(-1) })
+	wantPanic(t, "Add(1<<20)", sparseset.ErrKeyOutOfRange, func() { set.Add(1 << 20) })
+	wantPanic(t, "Remove(-1)", sparseset.ErrKeyOutOfRange, func() { set.Remove(-1) })
+	wantPanic(t, "Remove(1<<20)", sparseset.ErrKeyOutOfRange, func() { set.Remove(1 << 20) })
+}
@@ -0,0 +1,93 @@
+package sparseset
+
+import (
+	"fmt"
+	"strings"
+
+	"golang.org/x/exp/slices"
+)
+
+// Equal returns true if the sets have the same keys and eq returns true for the
+// values of each key. The order of the sets is ignored (see EqualOrdered()).
+func Equal[Value any, K Key](a, b *SetOf[K, Value], eq func(*Value, *Value) bool) bool {
+	if a.Length() != b.Length() {
+		return false
+	}
+
+	for posA, key := range a.dense {
+		posB, ok := b.position(key)
+		if !ok || !eq(&a.store[posA], &b.store[posB]) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// EqualOrdered is like Equal but the sets must also have the same order, i.e.,
+// the same keys at the same positions.
+func EqualOrdered[Value any, K Key](a, b *SetOf[K, Value], eq func(*Value, *Value) bool) bool {
+	if !slices.Equal(a.dense, b.dense) {
+		return false
+	}
+
+	for pos := range a.store {
+		if !eq(&a.store[pos], &b.store[pos]) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// DiffReport returns a human-readable report of the differences between the
+// sets, or the empty string if the sets are equal (see Equal()). The set 'a' is
+// the actual set and the set 'b' is the expected set, therefore the report
+// lists the keys of 'b' missing from 'a', the extra keys of 'a' that are not in
+// 'b', and the keys whose values changed, i.e., for which eq returns false. For
+// example:
+//
+//	if diff := sparseset.DiffReport(got, want, eq); diff != "" {
+//	  t.Errorf("set mismatch:\n%s", diff)
+//	}
+func DiffReport[Value any, K Key](a, b *SetOf[K, Value], eq func(*Value, *Value) bool) string {
+	var missing, extra, changed []K
+
+	for posA, key := range a.dense {
+		posB, ok := b.position(key)
+		if !ok {
+			extra = append(extra, key)
+		} else if !eq(&a.store[posA], &b.store[posB]) {
+			changed = append(changed, key)
+		}
+	}
+
+	for _, key := range b.dense {
+		if !a.Has(key) {
+			missing = append(missing, key)
+		}
+	}
+
+	slices.Sort(missing)
+	slices.Sort(extra)
+	slices.Sort(changed)
+
+	var report strings.Builder
+	for _, key := range missing {
+		value, _ := b.Get(key)
+		fmt.Fprintf(&report, "missing key %v: %+v\n", key, *value)
+	}
+
+	for _, key := range extra {
+		value, _ := a.Get(key)
+		fmt.Fprintf(&report, "extra key %v: %+v\n", key, *value)
+	}
+
+	for _, key := range changed {
+		valueA, _ := a.Get(key)
+		valueB, _ := b.Get(key)
+		fmt.Fprintf(&report, "changed key %v: got %+v; want %+v\n", key, *valueA, *valueB)
+	}
+
+	return report.String()
+}
@@ -0,0 +1,90 @@
+package sparseset_test
+
+import (
+	"testing"
+
+	"github.com/jabolopes/go-sparseset"
+	"golang.org/x/exp/slices"
+)
+
+func newSetFrom(keys ...int) *sparseset.Set[MyValue] {
+	set := sparseset.New[MyValue](1<<10, 1<<20)
+	for _, key := range keys {
+		set.Add(key).value = key
+	}
+	return set
+}
+
+func eqMyValue(a, b *MyValue) bool { return *a == *b }
+
+func TestEqual(t *testing.T) {
+	tests := []struct {
+		a, b        *sparseset.Set[MyValue]
+		want        bool
+		wantOrdered bool
+	}{
+		{newSetFrom(), newSetFrom(), true, true},
+		{newSetFrom(1, 2, 3), newSetFrom(1, 2, 3), true, true},
+		{newSetFrom(1, 2, 3), newSetFrom(3, 1, 2), true, false},
+		{newSetFrom(1, 2, 3), newSetFrom(1, 2), false, false},
+		{newSetFrom(1, 2), newSetFrom(1, 3), false, false},
+	}
+
+	for _, test := range tests {
+		if got := sparseset.Equal(test.a, test.b, eqMyValue); got != test.want {
+			t.Errorf("Equal(%v, %v) = %v; want %v", test.a.Keys(), test.b.Keys(), got, test.want)
+		}
+
+		if got := sparseset.EqualOrdered(test.a, test.b, eqMyValue); got != test.wantOrdered {
+			t.Errorf("EqualOrdered(%v, %v) = %v; want %v", test.a.Keys(), test.b.Keys(), got, test.wantOrdered)
+		}
+	}
+}
+
+func TestEqual_DifferentValues(t *testing.T) {
+	a := newSetFrom(1, 2)
+	b := newSetFrom(1, 2)
+	b.Add(2).value = 20
+
+	if sparseset.Equal(a, b, eqMyValue) {
+		t.Errorf("Equal() = %v; want %v", true, false)
+	}
+
+	if sparseset.EqualOrdered(a, b, eqMyValue) {
+		t.Errorf("EqualOrdered() = %v; want %v", true, false)
+	}
+}
+
+func TestDiffReport(t *testing.T) {
+	got := newSetFrom(4, 1, 2, 7)
+	want := newSetFrom(5, 2, 1, 3)
+	got.Add(2).value = 20
+
+	wantReport := "missing key 3: {value:3}\n" +
+		"missing key 5: {value:5}\n" +
+		"extra key 4: {value:4}\n" +
+		"extra key 7: {value:7}\n" +
+		"changed key 2: got {value:20}; want {value:2}\n"
+
+	if report := sparseset.DiffReport(got, want, eqMyValue); report != wantReport {
+		t.Errorf("DiffReport() = %q; want %q", report, wantReport)
+	}
+
+	if report := sparseset.DiffReport(want, want, eqMyValue); report != "" {
+		t.Errorf("DiffReport() = %q; want %q", report, "")
+	}
+}
+
+func TestDiffReport_NotComparable(t *testing.T) {
+	got := sparseset.New[[]int](1<<10, 1<<20)
+	want := sparseset.New[[]int](1<<10, 1<<20)
+	*got.Add(1) = []int{1, 2}
+	*want.Add(1) = []int{1, 2}
+	*got.Add(2) = []int{3}
+	*want.Add(2) = []int{4}
+
+	wantReport := "changed key 2: got [3]; want [4]\n"
+	if report := sparseset.DiffReport(got, want, func(a, b *[]int) bool { return slices.Equal(*a, *b) }); report != wantReport {
+		t.Errorf("DiffReport() = %q; want %q", report, wantReport)
+	}
+}
@@ -0,0 +1,22 @@
+package sparseset
+
+import (
+	"errors"
+	"fmt"
+)
+
+var (
+	// ErrKeyOutOfRange is returned when a key is negative or is not less than the
+	// null key of the set (or array).
+	ErrKeyOutOfRange = errors.New("sparseset: key out of range")
+	// ErrKeyNotFound is returned when a key is not in the set.
+	ErrKeyNotFound = errors.New("sparseset: key not found")
+	// ErrValueOutOfRange is returned when a value is not less than the null value
+	// of the array.
+	ErrValueOutOfRange = errors.New("sparseset: value out of range")
+)
+
+// keyError returns an error that wraps err and describes the key (or value).
+func keyError[T any](err error, key T) error {
+	return fmt.Errorf("%w: %v", err, key)
+}
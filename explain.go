@@ -0,0 +1,193 @@
+package sparseset
+
+import (
+	"fmt"
+
+	"golang.org/x/exp/slices"
+)
+
+// Number of keys of each set sampled by the adaptive joins to estimate the
+// overlap of the sets.
+const joinSampleSize = 64
+
+// JoinStats are the statistics of a join (see JoinIterator.Explain()).
+type JoinStats struct {
+	// Number of the set that drives the join (e.g., 1 for the first set), or 0
+	// if the join is a merge join or the join of a View.
+	Driver int
+	// True if the driver was chosen by the estimated cost of the join (see
+	// JoinIterator.Adaptive()), and false if it was chosen by the length of the
+	// sets or by the caller (see JoinDrivenBy()).
+	Adaptive bool
+	// True if the join walks the sets in order instead of looking up the keys of
+	// the driver, which requires the sets to be sorted by key and to have
+	// comparable lengths (see SortByKey()).
+	Merge bool
+	// Number of keys of the driver traversed so far. If Merge is true, this is
+	// the number of keys traversed in the set that advanced the furthest, such
+	// that the join of identical sets has a hit rate of 1.
+	Keys int
+	// Number of lookups of those keys in the other sets.
+	Probes int
+	// Number of keys that are in all the sets, i.e., the elements of the join.
+	Hits int
+}
+
+// HitRate returns the fraction of the keys of the driver (see Keys) that are in
+// all the sets, or 0 if no keys were traversed.
+func (s JoinStats) HitRate() float64 {
+	if s.Keys == 0 {
+		return 0
+	}
+	return float64(s.Hits) / float64(s.Keys)
+}
+
+func (s JoinStats) String() string {
+	if s.Merge {
+		return fmt.Sprintf("merge keys=%d hits=%d", s.Keys, s.Hits)
+	}
+
+	mode := "smallest"
+	if s.Adaptive {
+		mode = "adaptive"
+	}
+	return fmt.Sprintf("driver=set%d (%s) keys=%d probes=%d hits=%d hit rate=%.1f%%", s.Driver, mode, s.Keys, s.Probes, s.Hits, 100*s.HitRate())
+}
+
+// chooseDriver returns the index of the set that drives a join of the given
+// sets. This is the smallest set, or the set with the lowest estimated cost if
+// adaptive is true.
+func chooseDriver[K Key](sets []*sparse[K], adaptive bool) int {
+	driver := 0
+	for j := range sets {
+		if len(sets[j].dense) < len(sets[driver].dense) {
+			driver = j
+		}
+	}
+
+	if !adaptive {
+		return driver
+	}
+
+	bestCost := joinCost(sets, driver)
+	for j := range sets {
+		if cost := joinCost(sets, j); cost < bestCost {
+			driver, bestCost = j, cost
+		}
+	}
+	return driver
+}
+
+// joinCost estimates the cost of a join of the given sets driven by the set
+// with the given index, which is the cost of traversing the keys of the driver
+// and of probing the other sets, in order, until a key is missing. The number of
+// probes per key is estimated from a sample of the keys of the driver.
+func joinCost[K Key](sets []*sparse[K], driver int) float64 {
+	keys := sets[driver].dense
+	if len(keys) == 0 {
+		return 0
+	}
+
+	samples := min(len(keys), joinSampleSize)
+	probes := 0
+	for n := 0; n < samples; n++ {
+		// Sample keys evenly spaced across the driver.
+		key := keys[n*len(keys)/samples]
+
+		for j := range sets {
+			if j == driver {
+				continue
+			}
+
+			probes++
+			if !sets[j].Has(key) {
+				break
+			}
+		}
+	}
+
+	return float64(len(keys)) * (1 + float64(probes)/float64(samples))
+}
+
+// Minimum length of the sets of a merge join and maximum ratio between the
+// lengths of the largest and the smallest sets. Small sets are about as fast to
+// join with lookups, and a join of sets of very different lengths is cheaper
+// with lookups, which only traverse the smallest set.
+const (
+	mergeMinLength = 1024
+	mergeMaxRatio  = 4
+)
+
+// mergeable returns true if the join of the sets is a merge join, i.e., if all
+// the sets are sorted by key and their lengths are comparable.
+func mergeable[K Key](sets []*sparse[K]) bool {
+	smallest, largest := len(sets[0].dense), len(sets[0].dense)
+	for _, set := range sets {
+		if !set.sortedByKey {
+			return false
+		}
+		smallest = min(smallest, len(set.dense))
+		largest = max(largest, len(set.dense))
+	}
+	return smallest >= mergeMinLength && largest <= mergeMaxRatio*smallest
+}
+
+// mergeAdvance advances the cursors of the dense arrays, which are sorted by
+// key, to the next key that is in all of them. Returns false if there are no
+// more such keys. This is a leapfrog join, i.e., the cursors take turns jumping
+// to the largest key found so far until they all reach the same key.
+func mergeAdvance[K Key](dense [][]K, cursors []int) bool {
+	if cursors[0] >= len(dense[0]) {
+		return false
+	}
+
+	key := dense[0][cursors[0]]
+	for j, matched := 1%len(dense), 1; matched < len(dense); j = (j + 1) % len(dense) {
+		cursors[j] = gallop(dense[j], cursors[j], key)
+		if cursors[j] >= len(dense[j]) {
+			return false
+		}
+
+		if next := dense[j][cursors[j]]; next == key {
+			matched++
+		} else {
+			key, matched = next, 1
+		}
+	}
+	return true
+}
+
+// gallop returns the position of the first key of dense, which is sorted by
+// key, that is not less than key, starting at pos. This doubles the distance
+// from pos until it passes key and then binary searches the last interval,
+// therefore it costs O(log d), where d is the distance to the returned position.
+func gallop[K Key](dense []K, pos int, key K) int {
+	if pos >= len(dense) || dense[pos] >= key {
+		return pos
+	}
+
+	hi := pos
+	for step := 1; hi < len(dense) && dense[hi] < key; step *= 2 {
+		pos = hi + 1
+		hi += step
+	}
+	hi = min(hi, len(dense))
+
+	n, _ := slices.BinarySearch(dense[pos:hi], key)
+	return pos + n
+}
+
+// mergeKeys returns the number of keys traversed by a merge join, which is the
+// largest cursor (see JoinStats.Keys).
+func mergeKeys(cursors []int) int {
+	return slices.Max(cursors)
+}
+
+// mergeRemaining returns the maximum number of keys that remain in a merge join.
+func mergeRemaining[K Key](dense [][]K, cursors []int) int {
+	remaining := len(dense[0]) - cursors[0]
+	for j := range dense {
+		remaining = min(remaining, len(dense[j])-cursors[j])
+	}
+	return max(remaining, 0)
+}
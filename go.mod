@@ -1,6 +1,6 @@
 module github.com/jabolopes/go-sparseset
 
-go 1.21
+go 1.24
 
 require (
 	github.com/bxcodec/faker/v3 v3.8.0
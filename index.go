@@ -0,0 +1,256 @@
+package sparseset
+
+import (
+	"math"
+)
+
+// IndexWidth is the size of the positions stored in the index of a set. Sets
+// with narrower positions use less memory for their index.
+type IndexWidth int
+
+const (
+	// Positions are stored as int. This is the default.
+	IndexWidthInt IndexWidth = iota
+	// Positions are stored as uint16, which supports sets with up to 65535
+	// elements before the index is promoted to IndexWidth32.
+	IndexWidth16
+	// Positions are stored as uint32, which supports sets with up to 2^32-1
+	// elements before the index is promoted to IndexWidthInt.
+	IndexWidth32
+)
+
+// positionIndex is a paged array that stores positions by key. The positions
+// are stored in the narrowest array that is wide enough for them, starting with
+// the array of the width given at construction, and the index is promoted to a
+// wider array when a position does not fit.
+type positionIndex[K Key] struct {
+	// Exactly one of these arrays is not nil.
+	index16 *PagedArrayOf[K, uint16]
+	index32 *PagedArrayOf[K, uint32]
+	index   *PagedArrayOf[K, int]
+	// Number of positions per page.
+	pageSize int
+	// See PagedArrayOptions.MaxLinearPages.
+	maxLinearPages int
+	// Positions are less than nullValue.
+	nullValue int
+}
+
+func (i *positionIndex[K]) NullValue() int { return i.nullValue }
+
+func (i *positionIndex[K]) Width() IndexWidth {
+	switch {
+	case i.index16 != nil:
+		return IndexWidth16
+	case i.index32 != nil:
+		return IndexWidth32
+	default:
+		return IndexWidthInt
+	}
+}
+
+func (i *positionIndex[K]) Length() int {
+	switch {
+	case i.index16 != nil:
+		return i.index16.Length()
+	case i.index32 != nil:
+		return i.index32.Length()
+	default:
+		return i.index.Length()
+	}
+}
+
+func (i *positionIndex[K]) Get(key K) int {
+	// Fast path for the default width, whose positions need no conversion.
+	if i.index != nil {
+		return i.index.Get(key)
+	}
+	return i.getNarrow(key)
+}
+
+// getNarrow is Get for the narrow widths, which converts their null values to
+// the null value of the index.
+func (i *positionIndex[K]) getNarrow(key K) int {
+	if i.index16 != nil {
+		if pos := i.index16.Get(key); pos != math.MaxUint16 {
+			return int(pos)
+		}
+		return i.nullValue
+	}
+
+	if pos := i.index32.Get(key); pos != math.MaxUint32 {
+		return int(pos)
+	}
+	return i.nullValue
+}
+
+func (i *positionIndex[K]) Set(key K, pos int) {
+	if pos < 0 || pos >= i.nullValue {
+		return
+	}
+
+	if i.index != nil {
+		i.index.Set(key, pos)
+		return
+	}
+
+	i.widen(pos)
+
+	switch {
+	case i.index16 != nil:
+		i.index16.Set(key, uint16(pos))
+	case i.index32 != nil:
+		i.index32.Set(key, uint32(pos))
+	default:
+		i.index.Set(key, pos)
+	}
+}
+
+// SetRun sets the n consecutive keys starting at key to the consecutive
+// positions starting at pos.
+func (i *positionIndex[K]) SetRun(key K, pos, n int) {
+	if n <= 0 || pos < 0 || pos+n > i.nullValue {
+		return
+	}
+
+	i.widen(pos + n - 1)
+
+	switch {
+	case i.index16 != nil:
+		i.index16.setRun(key, n, func(j int) uint16 { return uint16(pos + j) })
+	case i.index32 != nil:
+		i.index32.setRun(key, n, func(j int) uint32 { return uint32(pos + j) })
+	default:
+		i.index.setRun(key, n, func(j int) int { return pos + j })
+	}
+}
+
+// widen promotes the index to a width that fits pos.
+func (i *positionIndex[K]) widen(pos int) {
+	if i.index16 != nil && pos >= math.MaxUint16 {
+		i.index32 = promote[K, uint16, uint32](i.index16, math.MaxUint32, i.pageSize, i.maxLinearPages)
+		i.index16 = nil
+	}
+
+	if i.index32 != nil && uint64(pos) >= math.MaxUint32 {
+		i.index = promote[K, uint32, int](i.index32, i.nullValue, i.pageSize, i.maxLinearPages)
+		i.index32 = nil
+	}
+}
+
+func (i *positionIndex[K]) Unset(key K) {
+	switch {
+	case i.index != nil:
+		i.index.Unset(key)
+	case i.index16 != nil:
+		i.index16.Unset(key)
+	default:
+		i.index32.Unset(key)
+	}
+}
+
+func (i *positionIndex[K]) Clear() {
+	switch {
+	case i.index16 != nil:
+		i.index16.Clear()
+	case i.index32 != nil:
+		i.index32.Clear()
+	default:
+		i.index.Clear()
+	}
+}
+
+func (i *positionIndex[K]) clone() *positionIndex[K] {
+	clone := *i
+	switch {
+	case i.index16 != nil:
+		clone.index16 = i.index16.clone()
+	case i.index32 != nil:
+		clone.index32 = i.index32.clone()
+	default:
+		clone.index = i.index.clone()
+	}
+	return &clone
+}
+
+// empty returns an empty index with the same page size, null value, width and
+// options.
+func (i *positionIndex[K]) empty() *positionIndex[K] {
+	options := PagedArrayOptions[int]{MaxLinearPages: i.maxLinearPages}
+	if i.index != nil {
+		options.Allocator = i.index.allocator
+	}
+	return newPositionIndex[K](i.pageSize, i.nullValue, i.Width(), options)
+}
+
+func (i *positionIndex[K]) Compact() {
+	switch {
+	case i.index16 != nil:
+		i.index16.Compact()
+	case i.index32 != nil:
+		i.index32.Compact()
+	default:
+		i.index.Compact()
+	}
+}
+
+func (i *positionIndex[K]) ShrinkToFit() {
+	switch {
+	case i.index16 != nil:
+		i.index16.ShrinkToFit()
+	case i.index32 != nil:
+		i.index32.ShrinkToFit()
+	default:
+		i.index.ShrinkToFit()
+	}
+}
+
+func (i *positionIndex[K]) Stats() PagedArrayStats {
+	switch {
+	case i.index16 != nil:
+		return i.index16.Stats()
+	case i.index32 != nil:
+		return i.index32.Stats()
+	default:
+		return i.index.Stats()
+	}
+}
+
+// promote copies the values of a narrow array into a new wider array and
+// clears the narrow array.
+func promote[K Key, Narrow, Wide uint16 | uint32 | int](narrow *PagedArrayOf[K, Narrow], nullValue Wide, pageSize, maxLinearPages int) *PagedArrayOf[K, Wide] {
+	wide := NewPagedArrayOfWithOptions[K](pageSize, nullValue, PagedArrayOptions[Wide]{MaxLinearPages: maxLinearPages})
+	narrow.forEach(func(key K, value Narrow) {
+		wide.Set(key, Wide(value))
+	})
+	narrow.Clear()
+	return wide
+}
+
+// newPositionIndex returns an index whose positions initially have the given
+// width. If options.Allocator is set, the positions are always int because the
+// allocator only allocates int pages.
+func newPositionIndex[K Key](defaultPageSize, nullValue int, width IndexWidth, options PagedArrayOptions[int]) *positionIndex[K] {
+	index := &positionIndex[K]{
+		nil, /* index16 */
+		nil, /* index32 */
+		nil, /* index */
+		defaultPageSize,
+		options.MaxLinearPages,
+		nullValue,
+	}
+
+	switch {
+	case options.Allocator != nil:
+		index.index = NewPagedArrayOfWithOptions[K](defaultPageSize, index.nullValue, options)
+		index.pageSize = options.Allocator.PageSize()
+	case width == IndexWidth16:
+		index.index16 = NewPagedArrayOfWithOptions[K](defaultPageSize, uint16(math.MaxUint16), PagedArrayOptions[uint16]{MaxLinearPages: options.MaxLinearPages})
+	case width == IndexWidth32:
+		index.index32 = NewPagedArrayOfWithOptions[K](defaultPageSize, uint32(math.MaxUint32), PagedArrayOptions[uint32]{MaxLinearPages: options.MaxLinearPages})
+	default:
+		index.index = NewPagedArrayOfWithOptions[K](defaultPageSize, index.nullValue, options)
+	}
+
+	return index
+}
@@ -1,17 +1,51 @@
 package sparseset
 
-type IteratorResult[A any] struct {
-	Key   int
+// IteratorResult is an IteratorResultOf with int keys.
+type IteratorResult[A any] = IteratorResultOf[int, A]
+
+type IteratorResultOf[K Key, A any] struct {
+	Key   K
 	Value *A
 }
 
-// Iterator can be used to traverse the keys and values of a Set. This iterator
-// is read-only (see thread-safety notes on Set).
+// Iterator is an IteratorOf with int keys.
+type Iterator[A any] = IteratorOf[int, A]
+
+// IteratorOf can be used to traverse the keys and values of a Set. This
+// iterator is read-only (see thread-safety notes on Set).
+//
+// The iterator of a Set is a plain struct that holds the keys and values of the
+// set and the position of the iteration, therefore it does not allocate memory
+// unless adapters are applied (e.g., Filter()). The zero value is an empty
+// iterator and an iterator can be reused with Reset(), e.g.:
+//
+//	var iterator sparseset.Iterator[Position]
+//	for iterator.Reset(positions); ; {
+//	  ...
+//	}
 //
 // This is thread-compatible.
-type Iterator[A any] struct {
-	get   func(int) (int, *A, bool)
+type IteratorOf[K Key, A any] struct {
+	// Stores keys and values by position. The iterator traverses these unless
+	// get is not nil.
+	dense []K
+	store []A
+	// If true, dense and store are traversed from the end.
+	reverse bool
+	// Number of positions of dense consumed by at().
+	consumed int
+	// If not nil, returns the elements of the iterator instead of dense and store
+	// (e.g., adapters).
+	get   func(int) (K, *A, bool)
 	index int
+	// Returns the lower and upper bounds of the number of remaining elements of
+	// get.
+	hint func() (int, int)
+	// If true, Peek() has buffered the next element (see Peek()).
+	peeked    bool
+	peekKey   K
+	peekValue *A
+	peekOk    bool
 }
 
 // Next returns the next value for this iterator. Returns a key, a value and a
@@ -19,8 +53,20 @@ type Iterator[A any] struct {
 // the key and value invalid (e.g., default initialized). If the boolean is
 // false, then the end of the iteration has been reached and subsequent calls to
 // Next() will not return any new elements.
-func (i *Iterator[A]) Next() (int, *A, bool) {
-	key, a, ok := i.get(i.index)
+func (i *IteratorOf[K, A]) Next() (K, *A, bool) {
+	var key K
+	var a *A
+	var ok bool
+	switch {
+	case i.peeked:
+		key, a, ok = i.peekKey, i.peekValue, i.peekOk
+		i.peeked = false
+	case i.get != nil:
+		key, a, ok = i.get(i.index)
+	default:
+		key, a, ok = i.at(i.index)
+	}
+
 	if !ok {
 		return 0, nil, false
 	}
@@ -29,40 +75,128 @@ func (i *Iterator[A]) Next() (int, *A, bool) {
 	return key, a, true
 }
 
+// Peek returns the next value for this iterator, like Next(), but without
+// advancing the iterator, i.e., the subsequent call to Next() or Peek() returns
+// the same value.
+func (i *IteratorOf[K, A]) Peek() (K, *A, bool) {
+	if !i.peeked {
+		if i.get != nil {
+			i.peekKey, i.peekValue, i.peekOk = i.get(i.index)
+		} else {
+			i.peekKey, i.peekValue, i.peekOk = i.at(i.index)
+		}
+		i.peeked = true
+	}
+	return i.peekKey, i.peekValue, i.peekOk
+}
+
+// SizeHint returns the lower and upper bounds of the number of remaining
+// elements of this iterator.
+func (i *IteratorOf[K, A]) SizeHint() (int, int) {
+	if i.peeked && !i.peekOk {
+		return 0, 0
+	}
+
+	var lower, upper int
+	if i.hint != nil {
+		lower, upper = i.hint()
+	} else {
+		lower, upper = i.denseHint()
+	}
+
+	if i.peeked {
+		return lower + 1, upper + 1
+	}
+	return lower, upper
+}
+
+// Reset restarts the iterator over the keys and values of the set (see
+// Iterate()). This also removes the adapters of the iterator.
+func (i *IteratorOf[K, A]) Reset(set *SetOf[K, A]) {
+	*i = IteratorOf[K, A]{dense: set.dense, store: set.store}
+}
+
+// at returns the key and value of the given element of dense and store.
+func (i *IteratorOf[K, A]) at(index int) (K, *A, bool) {
+	if index < 0 || index >= len(i.dense) {
+		return 0, nil, false
+	}
+
+	pos := index
+	if i.reverse {
+		pos = len(i.dense) - 1 - index
+	}
+
+	i.consumed = index + 1
+	return i.dense[pos], &i.store[pos], true
+}
+
+func (i *IteratorOf[K, A]) denseHint() (int, int) {
+	n := len(i.dense) - i.consumed
+	return n, n
+}
+
+// adapt returns the functions that adapters wrap.
+func (i *IteratorOf[K, A]) adapt() (func(int) (K, *A, bool), func() (int, int)) {
+	if i.get == nil {
+		return i.at, i.denseHint
+	}
+	return i.get, i.hint
+}
+
 // Collect traverses the remaining elements and stores them in an array. This is
 // more convenient than Next() but it performs memory allocations to create and
 // resize the array. If memory allocations are considered expensive (e.g.,
 // memory pressure, garbage collection, etc), then Next() should be preferred.
-func (i *Iterator[A]) Collect() []IteratorResult[A] {
-	results := []IteratorResult[A]{}
+func (i *IteratorOf[K, A]) Collect() []IteratorResultOf[K, A] {
+	results := []IteratorResultOf[K, A]{}
 	for {
 		key, value, ok := i.Next()
 		if !ok {
 			break
 		}
 
-		results = append(results, IteratorResult[A]{key, value})
+		results = append(results, IteratorResultOf[K, A]{key, value})
 	}
 	return results
 }
 
 // Iterate returns an iterator that can be used to traverse all the keys and
 // values of the set.
+func Iterate[A any, K Key](set *SetOf[K, A]) *IteratorOf[K, A] {
+	return &IteratorOf[K, A]{dense: set.dense, store: set.store}
+}
+
+// IterateReverse is like Iterate but traverses the set from the end.
 //
-// TODO: Avoid allocating memory for the Iterator itself.
-func Iterate[A any](set *Set[A]) *Iterator[A] {
-	dense := set.dense
-	store := set.store
-	get := func(i int) (int, *A, bool) {
-		if i < 0 || i >= len(dense) {
-			return 0, nil, false
-		}
-		return dense[i], &store[i], true
-	}
+// Unlike the other iterators, the set can be modified during the iteration by
+// removing the current key, e.g., to remove keys while traversing the set,
+// because Remove() only moves the last key, which was already traversed.
+// This does not hold if the ShrinkThreshold option is set, because Remove() can
+// then reallocate the values of the set and the iterator keeps returning
+// pointers to the old values, so writes through them are lost.
+func IterateReverse[A any, K Key](set *SetOf[K, A]) *IteratorOf[K, A] {
+	return &IteratorOf[K, A]{dense: set.dense, store: set.store, reverse: true}
+}
+
+// IterateRange returns an iterator that traverses the keys and values of the
+// set at the positions in the range [from, to). The range is clamped to the
+// positions of the set (see KeyAt()).
+func IterateRange[A any, K Key](set *SetOf[K, A], from, to int) *IteratorOf[K, A] {
+	to = min(max(to, 0), len(set.dense))
+	from = min(max(from, 0), to)
+	return &IteratorOf[K, A]{dense: set.dense[from:to], store: set.store[from:to]}
+}
 
-	return &Iterator[A]{get, 0}
+// newIterator returns an iterator whose elements are returned by get.
+func newIterator[K Key, A any](get func(int) (K, *A, bool), hint func() (int, int)) *IteratorOf[K, A] {
+	return &IteratorOf[K, A]{get: get, hint: hint}
 }
 
 func EmptyIterator[A any]() *Iterator[A] {
-	return &Iterator[A]{func(int) (int, *A, bool) { return 0, nil, false }, 0}
+	return EmptyIteratorOf[int, A]()
+}
+
+func EmptyIteratorOf[K Key, A any]() *IteratorOf[K, A] {
+	return &IteratorOf[K, A]{}
 }
@@ -147,3 +147,155 @@ func TestIterate_EmptySet(t *testing.T) {
 		}
 	}
 }
+
+// newBenchmarkSet returns a set with n keys that are multiples of step.
+func newBenchmarkSet(n, step int) *sparseset.Set[int] {
+	set := sparseset.New[int](4096, 1<<20)
+	for i := 0; i < n; i++ {
+		*set.Add(i * step) = i
+	}
+	return set
+}
+
+// newUnsortedSet is like newBenchmarkSet but the keys are added in descending
+// order, therefore the set is not sorted by key.
+func newUnsortedSet(n, step int) *sparseset.Set[int] {
+	set := sparseset.New[int](4096, 1<<20)
+	for i := n - 1; i >= 0; i-- {
+		*set.Add(i * step) = i
+	}
+	return set
+}
+
+func TestIterate_ZeroAllocs(t *testing.T) {
+	set := newBenchmarkSet(100, 1)
+
+	sum := 0
+	allocs := testing.AllocsPerRun(100, func() {
+		for iterator := sparseset.Iterate(set); ; {
+			_, a, ok := iterator.Next()
+			if !ok {
+				break
+			}
+			sum += *a
+		}
+	})
+
+	if allocs != 0 {
+		t.Errorf("Iterate() allocs = %v; want %v", allocs, 0)
+	}
+}
+
+func TestIterator_Reset(t *testing.T) {
+	set1 := newBenchmarkSet(3, 1)
+	set2 := newBenchmarkSet(2, 10)
+
+	var iterator sparseset.Iterator[int]
+	if _, _, ok := iterator.Next(); ok {
+		t.Errorf("Next() on zero value = _, _, %v; want _, _, %v", ok, false)
+	}
+
+	iterator.Reset(set1)
+	iterator.Next()
+	iterator.Reset(set2)
+
+	want := []iterateResult[int]{{0, 0, true}, {10, 1, true}}
+	if got := iterateAll(&iterator); !slices.Equal(got, want) {
+		t.Errorf("results = %v; want %v", got, want)
+	}
+}
+
+func BenchmarkIterate(b *testing.B) {
+	set := newBenchmarkSet(10000, 1)
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	sum := 0
+	for n := 0; n < b.N; n++ {
+		for iterator := sparseset.Iterate(set); ; {
+			_, a, ok := iterator.Next()
+			if !ok {
+				break
+			}
+			sum += *a
+		}
+	}
+}
+
+func BenchmarkIterator_Reset(b *testing.B) {
+	set := newBenchmarkSet(10000, 1)
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	sum := 0
+	var iterator sparseset.Iterator[int]
+	for n := 0; n < b.N; n++ {
+		for iterator.Reset(set); ; {
+			_, a, ok := iterator.Next()
+			if !ok {
+				break
+			}
+			sum += *a
+		}
+	}
+}
+
+func TestIterateReverse(t *testing.T) {
+	set := newBenchmarkSet(4, 1)
+
+	want := []iterateResult[int]{{3, 3, true}, {2, 2, true}, {1, 1, true}, {0, 0, true}}
+	if got := iterateAll(sparseset.IterateReverse(set)); !slices.Equal(got, want) {
+		t.Errorf("results = %v; want %v", got, want)
+	}
+
+	if got := iterateAll(sparseset.IterateReverse(sparseset.New[int](4096, 1<<20))); len(got) != 0 {
+		t.Errorf("results = %v; want %v", got, []iterateResult[int]{})
+	}
+}
+
+func TestIterateReverse_Remove(t *testing.T) {
+	set := newBenchmarkSet(10, 1)
+
+	visited := []int{}
+	for iterator := sparseset.IterateReverse(set); ; {
+		key, _, ok := iterator.Next()
+		if !ok {
+			break
+		}
+
+		visited = append(visited, key)
+		if key%3 != 0 {
+			set.Remove(key)
+		}
+	}
+
+	if want := []int{9, 8, 7, 6, 5, 4, 3, 2, 1, 0}; !slices.Equal(visited, want) {
+		t.Errorf("visited = %v; want %v", visited, want)
+	}
+
+	if got, want := set.Keys(), []int{0, 6, 9, 3}; !slices.Equal(got, want) {
+		t.Errorf("Keys() = %v; want %v", got, want)
+	}
+}
+
+func TestIterateRange(t *testing.T) {
+	set := newBenchmarkSet(5, 10)
+
+	tests := []struct {
+		from, to int
+		want     []iterateResult[int]
+	}{
+		{1, 3, []iterateResult[int]{{10, 1, true}, {20, 2, true}}},
+		{0, 5, []iterateResult[int]{{0, 0, true}, {10, 1, true}, {20, 2, true}, {30, 3, true}, {40, 4, true}}},
+		{-1, 1, []iterateResult[int]{{0, 0, true}}},
+		{4, 100, []iterateResult[int]{{40, 4, true}}},
+		{3, 3, []iterateResult[int]{}},
+		{4, 2, []iterateResult[int]{}},
+	}
+
+	for _, test := range tests {
+		if got := iterateAll(sparseset.IterateRange(set, test.from, test.to)); !slices.Equal(got, test.want) {
+			t.Errorf("IterateRange(%d, %d) = %v; want %v", test.from, test.to, got, test.want)
+		}
+	}
+}
@@ -1,57 +1,259 @@
 package sparseset
 
-type JoinIterator[A, B any] struct {
-	get func() (int, *A, *B, bool)
+import (
+	"fmt"
+)
+
+// JoinIterator is a JoinIteratorOf with int keys.
+type JoinIterator[A, B any] = JoinIteratorOf[int, A, B]
+
+// JoinIteratorOf traverses the keys that are in all the sets of the join and
+// their values. The smallest set drives the join, i.e., the iterator traverses
+// the keys of the smallest set and looks them up in the other sets (see
+// DrivenBy() and Adaptive()).
+//
+// If all the sets are sorted by key and have comparable lengths, the join is a
+// merge join instead, which traverses the sets in order (see SortByKey()).
+//
+// Like Iterator, this is a plain struct that does not allocate memory unless
+// adapters are applied (e.g., Filter()), the zero value is an empty iterator,
+// and an iterator can be reused with Reset().
+type JoinIteratorOf[K Key, A, B any] struct {
+	set1 *SetOf[K, A]
+	set2 *SetOf[K, B]
+	// Keys of the set that drives the join and the number of that set (e.g., 1
+	// for set1).
+	keys   []K
+	driver int
+	// If true, the driver was chosen (see drive()).
+	driven bool
+	// If true, the driver is chosen by the estimated cost of the join instead of
+	// the length of the sets (see Adaptive()).
+	adaptive bool
+	// Position of the next key in keys.
+	pos int
+	// If true, the join is a merge join, which traverses the sets in order with a
+	// cursor per set instead of a driver (see mergeable()).
+	merge   bool
+	cursors [2]int
+	// Statistics of the join (see Explain()).
+	probes int
+	hits   int
+	// If not nil, returns the elements of the iterator instead of the sets (e.g.,
+	// adapters).
+	get func() (K, *A, *B, bool)
+	// Returns the lower and upper bounds of the number of remaining elements of
+	// get.
+	hint func() (int, int)
+	// If true, Peek() has buffered the next element (see Peek()).
+	peeked  bool
+	peekKey K
+	peekA   *A
+	peekB   *B
+	peekOk  bool
+}
+
+func (i *JoinIteratorOf[K, A, B]) Next() (K, *A, *B, bool) {
+	switch {
+	case i.peeked:
+		i.peeked = false
+		return i.peekKey, i.peekA, i.peekB, i.peekOk
+	case i.get != nil:
+		return i.get()
+	default:
+		return i.next()
+	}
+}
+
+// Peek returns the next value for this iterator, like Next(), but without
+// advancing the iterator (see Iterator.Peek()).
+func (i *JoinIteratorOf[K, A, B]) Peek() (K, *A, *B, bool) {
+	if !i.peeked {
+		if i.get != nil {
+			i.peekKey, i.peekA, i.peekB, i.peekOk = i.get()
+		} else {
+			i.peekKey, i.peekA, i.peekB, i.peekOk = i.next()
+		}
+		i.peeked = true
+	}
+	return i.peekKey, i.peekA, i.peekB, i.peekOk
+}
+
+// SizeHint returns the lower and upper bounds of the number of remaining
+// elements of this iterator.
+func (i *JoinIteratorOf[K, A, B]) SizeHint() (int, int) {
+	if i.peeked && !i.peekOk {
+		return 0, 0
+	}
+
+	var lower, upper int
+	if i.hint != nil {
+		lower, upper = i.hint()
+	} else {
+		lower, upper = i.keysHint()
+	}
+
+	if i.peeked {
+		return lower + 1, upper + 1
+	}
+	return lower, upper
+}
+
+// Reset restarts the iterator over the join of the given sets (see Join()).
+// This also removes the adapters of the iterator.
+func (i *JoinIteratorOf[K, A, B]) Reset(set1 *SetOf[K, A], set2 *SetOf[K, B]) {
+	*i = JoinIteratorOf[K, A, B]{set1: set1, set2: set2}
+}
+
+// drive chooses the set that drives the join, unless it was chosen by the
+// caller (see DrivenBy()).
+func (i *JoinIteratorOf[K, A, B]) drive() {
+	i.driven = true
+	if i.set1 == nil {
+		// Empty iterator.
+		return
+	}
+
+	sets := [...]*sparse[K]{&i.set1.sparse, &i.set2.sparse}
+	if i.driver == 0 && !i.adaptive && mergeable(sets[:]) {
+		i.merge = true
+		return
+	}
+
+	if i.driver == 0 {
+		i.driver = chooseDriver(sets[:], i.adaptive) + 1
+	}
+	i.keys = sets[i.driver-1].dense
+}
+
+// DrivenBy forces the set with the given number (e.g., 1 for set1) to drive the
+// join. This must be called before the iteration starts. Returns the iterator
+// itself.
+func (i *JoinIteratorOf[K, A, B]) DrivenBy(driver int) *JoinIteratorOf[K, A, B] {
+	if driver < 1 || driver > 2 {
+		panic(fmt.Sprintf("sparseset: invalid join driver %d", driver))
+	}
+	i.driver = driver
+	return i
+}
+
+// Adaptive chooses the set that drives the join by the estimated cost of the
+// join, which is estimated by sampling the overlap of the sets, instead of the
+// length of the sets. This is better when the smallest set has little overlap
+// with the other sets. An adaptive join always has a driver, i.e., it is never
+// a merge join. This must be called before the iteration starts. Returns the
+// iterator itself.
+func (i *JoinIteratorOf[K, A, B]) Adaptive() *JoinIteratorOf[K, A, B] {
+	i.adaptive = true
+	return i
 }
 
-func (i *JoinIterator[A, B]) Next() (int, *A, *B, bool) {
-	return i.get()
+// Explain returns the statistics of the join so far.
+func (i *JoinIteratorOf[K, A, B]) Explain() JoinStats {
+	if !i.driven {
+		i.drive()
+	}
+	keys := i.pos
+	if i.merge {
+		keys = mergeKeys(i.cursors[:])
+	}
+	return JoinStats{i.driver, i.adaptive, i.merge, keys, i.probes, i.hits}
 }
 
-func Join[A, B any](set1 *Set[A], set2 *Set[B]) *JoinIterator[A, B] {
-	var get func() (int, *A, *B, bool)
+func (i *JoinIteratorOf[K, A, B]) next() (K, *A, *B, bool) {
+	if !i.driven {
+		i.drive()
+	}
 
-	if len(set1.dense) <= len(set2.dense) {
-		iterator := Iterate(set1)
-		get = func() (int, *A, *B, bool) {
-			for {
-				key, a, ok := iterator.Next()
-				if !ok {
-					return 0, nil, nil, false
-				}
+	if i.merge {
+		return i.mergeNext()
+	}
 
-				b, ok := set2.Get(key)
-				if !ok {
-					continue
-				}
+	for i.pos < len(i.keys) {
+		pos, key := i.pos, i.keys[i.pos]
+		i.pos++
 
-				return key, a, b, true
-			}
+		a, ok := joinGet(i.set1, key, pos, i.driver == 1, &i.probes)
+		if !ok {
+			continue
 		}
-	} else {
-		iterator := Iterate(set2)
-		get = func() (int, *A, *B, bool) {
-			for {
-				key, b, ok := iterator.Next()
-				if !ok {
-					return 0, nil, nil, false
-				}
-
-				a, ok := set1.Get(key)
-				if !ok {
-					continue
-				}
-
-				return key, a, b, true
-			}
+
+		b, ok := joinGet(i.set2, key, pos, i.driver == 2, &i.probes)
+		if !ok {
+			continue
 		}
+
+		i.hits++
+		return key, a, b, true
 	}
 
-	return &JoinIterator[A, B]{get}
+	return 0, nil, nil, false
 }
 
-func EmptyJoinIterator[A, B any]() *JoinIterator[A, B] {
-	return &JoinIterator[A, B]{func() (int, *A, *B, bool) {
+// mergeNext returns the next element of a merge join.
+func (i *JoinIteratorOf[K, A, B]) mergeNext() (K, *A, *B, bool) {
+	dense := [...][]K{i.set1.dense, i.set2.dense}
+	if !mergeAdvance(dense[:], i.cursors[:]) {
 		return 0, nil, nil, false
-	}}
+	}
+
+	pos := i.cursors
+	for j := range i.cursors {
+		i.cursors[j]++
+	}
+
+	i.hits++
+	return dense[0][pos[0]], &i.set1.store[pos[0]], &i.set2.store[pos[1]], true
+}
+
+// keysHint returns the size hint of the join, which has no more elements than
+// the remaining keys but can have none.
+func (i *JoinIteratorOf[K, A, B]) keysHint() (int, int) {
+	if !i.driven {
+		i.drive()
+	}
+
+	if i.merge {
+		dense := [...][]K{i.set1.dense, i.set2.dense}
+		return 0, mergeRemaining(dense[:], i.cursors[:])
+	}
+	return 0, len(i.keys) - i.pos
+}
+
+// adapt returns the functions that adapters wrap.
+func (i *JoinIteratorOf[K, A, B]) adapt() (func() (K, *A, *B, bool), func() (int, int)) {
+	if i.get == nil {
+		return i.next, i.keysHint
+	}
+	return i.get, i.hint
+}
+
+func Join[A, B any, K Key](set1 *SetOf[K, A], set2 *SetOf[K, B]) *JoinIteratorOf[K, A, B] {
+	return &JoinIteratorOf[K, A, B]{set1: set1, set2: set2}
+}
+
+// JoinDrivenBy is like Join but the set with the given number (e.g., 1 for set1)
+// drives the join (see DrivenBy()).
+func JoinDrivenBy[A, B any, K Key](driver int, set1 *SetOf[K, A], set2 *SetOf[K, B]) *JoinIteratorOf[K, A, B] {
+	return Join(set1, set2).DrivenBy(driver)
+}
+
+func EmptyJoinIterator[A, B any]() *JoinIterator[A, B] {
+	return EmptyJoinIteratorOf[int, A, B]()
+}
+
+func EmptyJoinIteratorOf[K Key, A, B any]() *JoinIteratorOf[K, A, B] {
+	return &JoinIteratorOf[K, A, B]{}
+}
+
+// joinGet returns the value of key in set. If the set drives the join, the
+// value is at position pos and the lookup is skipped, otherwise the lookup is
+// counted in probes.
+func joinGet[K Key, A any](set *SetOf[K, A], key K, pos int, driver bool, probes *int) (*A, bool) {
+	if driver {
+		return &set.store[pos], true
+	}
+
+	*probes++
+	return set.Get(key)
 }
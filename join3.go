@@ -1,89 +1,254 @@
 package sparseset
 
-type Join3Iterator[A, B, C any] struct {
-	get func() (int, *A, *B, *C, bool)
+import (
+	"fmt"
+)
+
+// Join3Iterator is a Join3IteratorOf with int keys.
+type Join3Iterator[A, B, C any] = Join3IteratorOf[int, A, B, C]
+
+// Join3IteratorOf traverses the keys that are in all the sets of the join and
+// their values. The smallest set drives the join, i.e., the iterator traverses
+// the keys of the smallest set and looks them up in the other sets (see
+// DrivenBy() and Adaptive()).
+//
+// If all the sets are sorted by key and have comparable lengths, the join is a
+// merge join instead, which traverses the sets in order (see SortByKey()).
+//
+// Like Iterator, this is a plain struct that does not allocate memory unless
+// adapters are applied (e.g., Filter()), the zero value is an empty iterator,
+// and an iterator can be reused with Reset().
+type Join3IteratorOf[K Key, A, B, C any] struct {
+	set1 *SetOf[K, A]
+	set2 *SetOf[K, B]
+	set3 *SetOf[K, C]
+	// Keys of the set that drives the join and the number of that set (e.g., 1
+	// for set1).
+	keys   []K
+	driver int
+	// If true, the driver was chosen (see drive()).
+	driven bool
+	// If true, the driver is chosen by the estimated cost of the join instead of
+	// the length of the sets (see Adaptive()).
+	adaptive bool
+	// Position of the next key in keys.
+	pos int
+	// If true, the join is a merge join, which traverses the sets in order with a
+	// cursor per set instead of a driver (see mergeable()).
+	merge   bool
+	cursors [3]int
+	// Statistics of the join (see Explain()).
+	probes int
+	hits   int
+	// If not nil, returns the elements of the iterator instead of the sets (e.g.,
+	// adapters).
+	get func() (K, *A, *B, *C, bool)
+	// Returns the lower and upper bounds of the number of remaining elements of
+	// get.
+	hint func() (int, int)
+	// If true, Peek() has buffered the next element (see Peek()).
+	peeked  bool
+	peekKey K
+	peekA   *A
+	peekB   *B
+	peekC   *C
+	peekOk  bool
+}
+
+func (i *Join3IteratorOf[K, A, B, C]) Next() (K, *A, *B, *C, bool) {
+	switch {
+	case i.peeked:
+		i.peeked = false
+		return i.peekKey, i.peekA, i.peekB, i.peekC, i.peekOk
+	case i.get != nil:
+		return i.get()
+	default:
+		return i.next()
+	}
+}
+
+// Peek returns the next value for this iterator, like Next(), but without
+// advancing the iterator (see Iterator.Peek()).
+func (i *Join3IteratorOf[K, A, B, C]) Peek() (K, *A, *B, *C, bool) {
+	if !i.peeked {
+		if i.get != nil {
+			i.peekKey, i.peekA, i.peekB, i.peekC, i.peekOk = i.get()
+		} else {
+			i.peekKey, i.peekA, i.peekB, i.peekC, i.peekOk = i.next()
+		}
+		i.peeked = true
+	}
+	return i.peekKey, i.peekA, i.peekB, i.peekC, i.peekOk
+}
+
+// SizeHint returns the lower and upper bounds of the number of remaining
+// elements of this iterator.
+func (i *Join3IteratorOf[K, A, B, C]) SizeHint() (int, int) {
+	if i.peeked && !i.peekOk {
+		return 0, 0
+	}
+
+	var lower, upper int
+	if i.hint != nil {
+		lower, upper = i.hint()
+	} else {
+		lower, upper = i.keysHint()
+	}
+
+	if i.peeked {
+		return lower + 1, upper + 1
+	}
+	return lower, upper
 }
 
-func (i *Join3Iterator[A, B, C]) Next() (int, *A, *B, *C, bool) {
-	return i.get()
+// Reset restarts the iterator over the join of the given sets (see Join3()).
+// This also removes the adapters of the iterator.
+func (i *Join3IteratorOf[K, A, B, C]) Reset(set1 *SetOf[K, A], set2 *SetOf[K, B], set3 *SetOf[K, C]) {
+	*i = Join3IteratorOf[K, A, B, C]{set1: set1, set2: set2, set3: set3}
 }
 
-func Join3[A, B, C any](set1 *Set[A], set2 *Set[B], set3 *Set[C]) *Join3Iterator[A, B, C] {
-	var get func() (int, *A, *B, *C, bool)
+// drive chooses the set that drives the join, unless it was chosen by the
+// caller (see DrivenBy()).
+func (i *Join3IteratorOf[K, A, B, C]) drive() {
+	i.driven = true
+	if i.set1 == nil {
+		// Empty iterator.
+		return
+	}
+
+	sets := [...]*sparse[K]{&i.set1.sparse, &i.set2.sparse, &i.set3.sparse}
+	if i.driver == 0 && !i.adaptive && mergeable(sets[:]) {
+		i.merge = true
+		return
+	}
+
+	if i.driver == 0 {
+		i.driver = chooseDriver(sets[:], i.adaptive) + 1
+	}
+	i.keys = sets[i.driver-1].dense
+}
+
+// DrivenBy forces the set with the given number (e.g., 1 for set1) to drive the
+// join. This must be called before the iteration starts. Returns the iterator
+// itself.
+func (i *Join3IteratorOf[K, A, B, C]) DrivenBy(driver int) *Join3IteratorOf[K, A, B, C] {
+	if driver < 1 || driver > 3 {
+		panic(fmt.Sprintf("sparseset: invalid join driver %d", driver))
+	}
+	i.driver = driver
+	return i
+}
+
+// Adaptive chooses the set that drives the join by the estimated cost of the
+// join, which is estimated by sampling the overlap of the sets, instead of the
+// length of the sets. This is better when the smallest set has little overlap
+// with the other sets. An adaptive join always has a driver, i.e., it is never
+// a merge join. This must be called before the iteration starts. Returns the
+// iterator itself.
+func (i *Join3IteratorOf[K, A, B, C]) Adaptive() *Join3IteratorOf[K, A, B, C] {
+	i.adaptive = true
+	return i
+}
+
+// Explain returns the statistics of the join so far.
+func (i *Join3IteratorOf[K, A, B, C]) Explain() JoinStats {
+	if !i.driven {
+		i.drive()
+	}
+	keys := i.pos
+	if i.merge {
+		keys = mergeKeys(i.cursors[:])
+	}
+	return JoinStats{i.driver, i.adaptive, i.merge, keys, i.probes, i.hits}
+}
 
-	if len(set1.dense) <= len(set2.dense) && len(set1.dense) <= len(set3.dense) {
-		iterator := Iterate(set1)
-		get = func() (int, *A, *B, *C, bool) {
-			for {
-				key, a, ok := iterator.Next()
-				if !ok {
-					return 0, nil, nil, nil, false
-				}
+func (i *Join3IteratorOf[K, A, B, C]) next() (K, *A, *B, *C, bool) {
+	if !i.driven {
+		i.drive()
+	}
 
-				b, ok := set2.Get(key)
-				if !ok {
-					continue
-				}
+	if i.merge {
+		return i.mergeNext()
+	}
 
-				c, ok := set3.Get(key)
-				if !ok {
-					continue
-				}
+	for i.pos < len(i.keys) {
+		pos, key := i.pos, i.keys[i.pos]
+		i.pos++
 
-				return key, a, b, c, true
-			}
+		a, ok := joinGet(i.set1, key, pos, i.driver == 1, &i.probes)
+		if !ok {
+			continue
 		}
-	} else if len(set2.dense) <= len(set1.dense) && len(set2.dense) <= len(set3.dense) {
-		iterator := Iterate(set2)
-		get = func() (int, *A, *B, *C, bool) {
-			for {
-				key, b, ok := iterator.Next()
-				if !ok {
-					return 0, nil, nil, nil, false
-				}
-
-				a, ok := set1.Get(key)
-				if !ok {
-					continue
-				}
-
-				c, ok := set3.Get(key)
-				if !ok {
-					continue
-				}
-
-				return key, a, b, c, true
-			}
+
+		b, ok := joinGet(i.set2, key, pos, i.driver == 2, &i.probes)
+		if !ok {
+			continue
 		}
-	} else {
-		iterator := Iterate(set3)
-		get = func() (int, *A, *B, *C, bool) {
-			for {
-				key, c, ok := iterator.Next()
-				if !ok {
-					return 0, nil, nil, nil, false
-				}
-
-				a, ok := set1.Get(key)
-				if !ok {
-					continue
-				}
-
-				b, ok := set2.Get(key)
-				if !ok {
-					continue
-				}
-
-				return key, a, b, c, true
-			}
+
+		c, ok := joinGet(i.set3, key, pos, i.driver == 3, &i.probes)
+		if !ok {
+			continue
 		}
+
+		i.hits++
+		return key, a, b, c, true
 	}
 
-	return &Join3Iterator[A, B, C]{get}
+	return 0, nil, nil, nil, false
 }
 
-func EmptyJoin3Iterator[A, B, C any]() *Join3Iterator[A, B, C] {
-	return &Join3Iterator[A, B, C]{func() (int, *A, *B, *C, bool) {
+// mergeNext returns the next element of a merge join.
+func (i *Join3IteratorOf[K, A, B, C]) mergeNext() (K, *A, *B, *C, bool) {
+	dense := [...][]K{i.set1.dense, i.set2.dense, i.set3.dense}
+	if !mergeAdvance(dense[:], i.cursors[:]) {
 		return 0, nil, nil, nil, false
-	}}
+	}
+
+	pos := i.cursors
+	for j := range i.cursors {
+		i.cursors[j]++
+	}
+
+	i.hits++
+	return dense[0][pos[0]], &i.set1.store[pos[0]], &i.set2.store[pos[1]], &i.set3.store[pos[2]], true
+}
+
+// keysHint returns the size hint of the join, which has no more elements than
+// the remaining keys but can have none.
+func (i *Join3IteratorOf[K, A, B, C]) keysHint() (int, int) {
+	if !i.driven {
+		i.drive()
+	}
+
+	if i.merge {
+		dense := [...][]K{i.set1.dense, i.set2.dense, i.set3.dense}
+		return 0, mergeRemaining(dense[:], i.cursors[:])
+	}
+	return 0, len(i.keys) - i.pos
+}
+
+// adapt returns the functions that adapters wrap.
+func (i *Join3IteratorOf[K, A, B, C]) adapt() (func() (K, *A, *B, *C, bool), func() (int, int)) {
+	if i.get == nil {
+		return i.next, i.keysHint
+	}
+	return i.get, i.hint
+}
+
+func Join3[A, B, C any, K Key](set1 *SetOf[K, A], set2 *SetOf[K, B], set3 *SetOf[K, C]) *Join3IteratorOf[K, A, B, C] {
+	return &Join3IteratorOf[K, A, B, C]{set1: set1, set2: set2, set3: set3}
+}
+
+// Join3DrivenBy is like Join3 but the set with the given number (e.g., 1 for set1)
+// drives the join (see DrivenBy()).
+func Join3DrivenBy[A, B, C any, K Key](driver int, set1 *SetOf[K, A], set2 *SetOf[K, B], set3 *SetOf[K, C]) *Join3IteratorOf[K, A, B, C] {
+	return Join3(set1, set2, set3).DrivenBy(driver)
+}
+
+func EmptyJoin3Iterator[A, B, C any]() *Join3Iterator[A, B, C] {
+	return EmptyJoin3IteratorOf[int, A, B, C]()
+}
+
+func EmptyJoin3IteratorOf[K Key, A, B, C any]() *Join3IteratorOf[K, A, B, C] {
+	return &Join3IteratorOf[K, A, B, C]{}
 }
@@ -221,3 +221,86 @@ func TestJoin3_OneSetIsEmpty(t *testing.T) {
 		t.Errorf("results = %v; want %v", got, want)
 	}
 }
+
+func BenchmarkJoin3(b *testing.B) {
+	set1 := newBenchmarkSet(10000, 1)
+	set2 := newBenchmarkSet(10000, 2)
+	set3 := newBenchmarkSet(10000, 3)
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	sum := 0
+	for n := 0; n < b.N; n++ {
+		for iterator := sparseset.Join3(set1, set2, set3); ; {
+			_, a, b, c, ok := iterator.Next()
+			if !ok {
+				break
+			}
+			sum += *a + *b + *c
+		}
+	}
+}
+
+func TestJoin3_Adaptive(t *testing.T) {
+	// The smallest set (set1) is a subset of set2 and it is disjoint with set3,
+	// therefore each key of set1 is probed in both set2 and set3, whereas each
+	// key of set3 is only probed in set1.
+	set1 := newBenchmarkSet(100, 1)
+	set2 := newBenchmarkSet(200, 1)
+	set3 := sparseset.New[int](4096, 1<<20)
+	for i := 0; i < 110; i++ {
+		*set3.Add(1000 + i) = i
+	}
+
+	smallest := sparseset.Join3(set1, set2, set3)
+	join3All(smallest)
+
+	if got, want := smallest.Explain(), (sparseset.JoinStats{Driver: 1, Adaptive: false, Keys: 100, Probes: 200, Hits: 0}); got != want {
+		t.Errorf("Explain() = %v; want %v", got, want)
+	}
+
+	adaptive := sparseset.Join3(set1, set2, set3).Adaptive()
+	join3All(adaptive)
+
+	if got, want := adaptive.Explain(), (sparseset.JoinStats{Driver: 3, Adaptive: true, Keys: 110, Probes: 110, Hits: 0}); got != want {
+		t.Errorf("Explain() = %v; want %v", got, want)
+	}
+}
+
+func TestJoin3_AdaptiveResults(t *testing.T) {
+	set1 := newBenchmarkSet(50, 1)
+	set2 := newBenchmarkSet(50, 2)
+	set3 := newBenchmarkSet(50, 3)
+
+	want := join3All(sparseset.Join3(set1, set2, set3))
+	slices.SortFunc(want, func(r1, r2 join3Result[int, int, int]) int { return cmp.Compare(r1.key, r2.key) })
+
+	for driver := 1; driver <= 3; driver++ {
+		got := join3All(sparseset.Join3DrivenBy(driver, set1, set2, set3))
+		slices.SortFunc(got, func(r1, r2 join3Result[int, int, int]) int { return cmp.Compare(r1.key, r2.key) })
+
+		if !slices.Equal(got, want) {
+			t.Errorf("Join3DrivenBy(%d) = %v; want %v", driver, got, want)
+		}
+	}
+
+	got := join3All(sparseset.Join3(set1, set2, set3).Adaptive())
+	slices.SortFunc(got, func(r1, r2 join3Result[int, int, int]) int { return cmp.Compare(r1.key, r2.key) })
+
+	if !slices.Equal(got, want) {
+		t.Errorf("Adaptive() = %v; want %v", got, want)
+	}
+}
+
+func TestJoin3_MergeHitRate(t *testing.T) {
+	set1 := newBenchmarkSet(2000, 1)
+	set2 := newBenchmarkSet(2000, 1)
+	set3 := newBenchmarkSet(2000, 1)
+
+	iterator := sparseset.Join3(set1, set2, set3)
+	join3All(iterator)
+
+	if stats := iterator.Explain(); !stats.Merge || stats.Keys != 2000 || stats.HitRate() != 1 {
+		t.Errorf("Explain() = %v, HitRate() = %v; want merge with %d keys and hit rate %v", stats, stats.HitRate(), 2000, 1)
+	}
+}
@@ -267,3 +267,75 @@ func TestJoin4_OneSetIsEmpty(t *testing.T) {
 		t.Errorf("results = %v; want %v", got, want)
 	}
 }
+
+func TestJoin4_ZeroAllocs(t *testing.T) {
+	set1 := newBenchmarkSet(100, 1)
+	set2 := newBenchmarkSet(100, 2)
+
+	sum := 0
+	allocs := testing.AllocsPerRun(100, func() {
+		for iterator := sparseset.Join4(set1, set2, set1, set2); ; {
+			_, a, b, c, d, ok := iterator.Next()
+			if !ok {
+				break
+			}
+			sum += *a + *b + *c + *d
+		}
+	})
+
+	if allocs != 0 {
+		t.Errorf("Join4() allocs = %v; want %v", allocs, 0)
+	}
+}
+
+func BenchmarkJoin4(b *testing.B) {
+	set1 := newBenchmarkSet(10000, 1)
+	set2 := newBenchmarkSet(10000, 2)
+	set3 := newBenchmarkSet(10000, 3)
+	set4 := newBenchmarkSet(10000, 4)
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	sum := 0
+	for n := 0; n < b.N; n++ {
+		for iterator := sparseset.Join4(set1, set2, set3, set4); ; {
+			_, a, b, c, d, ok := iterator.Next()
+			if !ok {
+				break
+			}
+			sum += *a + *b + *c + *d
+		}
+	}
+}
+
+func TestJoin4DrivenBy(t *testing.T) {
+	set1 := newBenchmarkSet(20, 1)
+	set2 := newBenchmarkSet(20, 2)
+
+	iterator := sparseset.Join4DrivenBy(4, set1, set1, set1, set2)
+	results := join4All(iterator)
+
+	if got := iterator.Explain(); got.Driver != 4 || got.Keys != 20 || got.Hits != len(results) || got.Hits != 10 {
+		t.Errorf("Explain() = %v; want driver %d, keys %d, hits %d", got, 4, 20, 10)
+	}
+}
+
+func TestJoin4_Merge(t *testing.T) {
+	set1 := newBenchmarkSet(2000, 1)
+	set2 := newBenchmarkSet(2000, 2)
+	set3 := newBenchmarkSet(2000, 3)
+	set4 := newBenchmarkSet(2000, 5)
+
+	iterator := sparseset.Join4(set1, set2, set3, set4)
+	got := join4All(iterator)
+
+	want := join4All(sparseset.Join4DrivenBy(4, set1, set2, set3, set4))
+	if !slices.Equal(got, want) {
+		t.Errorf("results = %v; want %v", got, want)
+	}
+
+	// The keys of set1 that are multiples of 2, 3 and 5.
+	if len(want) != 67 || !iterator.Explain().Merge {
+		t.Errorf("len(results) = %d, Explain() = %v; want %d results with merge", len(want), iterator.Explain(), 67)
+	}
+}
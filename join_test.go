@@ -186,3 +186,255 @@ func TestJoin_OneSetIsEmpty(t *testing.T) {
 		t.Errorf("results = %v; want %v", got, want)
 	}
 }
+
+func TestJoin_ZeroAllocs(t *testing.T) {
+	set1 := newBenchmarkSet(100, 1)
+	set2 := newBenchmarkSet(100, 2)
+
+	sum := 0
+	allocs := testing.AllocsPerRun(100, func() {
+		for iterator := sparseset.Join(set1, set2); ; {
+			_, a, b, ok := iterator.Next()
+			if !ok {
+				break
+			}
+			sum += *a + *b
+		}
+	})
+
+	if allocs != 0 {
+		t.Errorf("Join() allocs = %v; want %v", allocs, 0)
+	}
+}
+
+func TestJoinIterator_Reset(t *testing.T) {
+	set1 := newBenchmarkSet(3, 1)
+	set2 := newBenchmarkSet(3, 2)
+
+	var iterator sparseset.JoinIterator[int, int]
+	if _, _, _, ok := iterator.Next(); ok {
+		t.Errorf("Next() on zero value = _, _, _, %v; want _, _, _, %v", ok, false)
+	}
+
+	for i := 0; i < 2; i++ {
+		iterator.Reset(set1, set2)
+
+		want := []joinResult[int, int]{{0, 0, 0, true}, {2, 2, 1, true}}
+		if got := joinAll(&iterator); !slices.Equal(got, want) {
+			t.Errorf("results = %v; want %v", got, want)
+		}
+	}
+}
+
+func BenchmarkJoin(b *testing.B) {
+	set1 := newBenchmarkSet(10000, 1)
+	set2 := newBenchmarkSet(10000, 2)
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	sum := 0
+	for n := 0; n < b.N; n++ {
+		for iterator := sparseset.Join(set1, set2); ; {
+			_, a, b, ok := iterator.Next()
+			if !ok {
+				break
+			}
+			sum += *a + *b
+		}
+	}
+}
+
+func TestJoin_Explain(t *testing.T) {
+	set1 := newBenchmarkSet(10, 1)
+	set2 := newBenchmarkSet(10, 2)
+
+	iterator := sparseset.Join(set1, set2)
+	joinAll(iterator)
+
+	want := sparseset.JoinStats{Driver: 1, Adaptive: false, Keys: 10, Probes: 10, Hits: 5}
+	if got := iterator.Explain(); got != want {
+		t.Errorf("Explain() = %v; want %v", got, want)
+	}
+
+	if got, want := iterator.Explain().HitRate(), 0.5; got != want {
+		t.Errorf("HitRate() = %v; want %v", got, want)
+	}
+
+	if got, want := iterator.Explain().String(), "driver=set1 (smallest) keys=10 probes=10 hits=5 hit rate=50.0%"; got != want {
+		t.Errorf("String() = %q; want %q", got, want)
+	}
+}
+
+func TestJoinDrivenBy(t *testing.T) {
+	set1 := newBenchmarkSet(10, 1)
+	set2 := newBenchmarkSet(10, 2)
+
+	iterator := sparseset.JoinDrivenBy(2, set1, set2)
+
+	want := []joinResult[int, int]{{0, 0, 0, true}, {2, 2, 1, true}, {4, 4, 2, true}, {6, 6, 3, true}, {8, 8, 4, true}}
+	if got := joinAll(iterator); !slices.Equal(got, want) {
+		t.Errorf("results = %v; want %v", got, want)
+	}
+
+	if got := iterator.Explain(); got.Driver != 2 || got.Keys != 10 || got.Hits != 5 {
+		t.Errorf("Explain() = %v; want driver %d, keys %d, hits %d", got, 2, 10, 5)
+	}
+}
+
+func TestJoin_DrivenByInvalid(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Errorf("DrivenBy(3) did not panic")
+		}
+	}()
+
+	sparseset.Join(newBenchmarkSet(1, 1), newBenchmarkSet(1, 1)).DrivenBy(3)
+}
+
+func TestJoin_Merge(t *testing.T) {
+	set1 := newBenchmarkSet(2000, 2)
+	set2 := newBenchmarkSet(2000, 3)
+
+	iterator := sparseset.Join(set1, set2)
+	got := joinAll(iterator)
+
+	want := joinAll(sparseset.JoinDrivenBy(1, set1, set2))
+	if !slices.Equal(got, want) {
+		t.Errorf("results = %v; want %v", got, want)
+	}
+
+	if stats := iterator.Explain(); !stats.Merge || stats.Driver != 0 || stats.Probes != 0 || stats.Hits != len(want) {
+		t.Errorf("Explain() = %v; want merge with %d hits", stats, len(want))
+	}
+
+	set2.Add(1)
+	if stats := sparseset.Join(set1, set2).Explain(); stats.Merge {
+		t.Errorf("Explain() = %v; want no merge after unsorted Add()", stats)
+	}
+}
+
+func TestJoin_MergeHitRate(t *testing.T) {
+	set1 := newBenchmarkSet(2000, 1)
+	set2 := newBenchmarkSet(2000, 1)
+
+	iterator := sparseset.Join(set1, set2)
+	joinAll(iterator)
+
+	// The join of identical sets has a hit rate of 1, like a join with lookups.
+	if stats := iterator.Explain(); !stats.Merge || stats.Keys != 2000 || stats.HitRate() != 1 {
+		t.Errorf("Explain() = %v, HitRate() = %v; want merge with %d keys and hit rate %v", stats, stats.HitRate(), 2000, 1)
+	}
+
+	set3 := newBenchmarkSet(2000, 2)
+	iterator = sparseset.Join(set1, set3)
+	joinAll(iterator)
+
+	// Only the even keys of set1, which is traversed up to the last key of set3,
+	// are in both sets.
+	if stats := iterator.Explain(); !stats.Merge || stats.HitRate() < 0.49 || stats.HitRate() > 0.51 {
+		t.Errorf("Explain() = %v, HitRate() = %v; want merge with hit rate %v", stats, stats.HitRate(), 0.5)
+	}
+}
+
+func TestJoin_MergeRand(t *testing.T) {
+	const n = 4000
+
+	set1 := sparseset.New[int](4096, 1<<20)
+	set2 := sparseset.New[int](4096, 1<<20)
+	for i := 0; i < n; i++ {
+		*set1.Add(rand.Intn(2 * n)) = i
+		*set2.Add(rand.Intn(2 * n)) = i
+	}
+
+	want := joinAll(sparseset.Join(set1, set2))
+	slices.SortFunc(want, func(r1, r2 joinResult[int, int]) int { return cmp.Compare(r1.key, r2.key) })
+
+	sparseset.SortByKey(set1)
+	sparseset.SortByKey(set2)
+
+	iterator := sparseset.Join(set1, set2)
+	if lower, upper := iterator.SizeHint(); lower != 0 || upper != min(set1.Length(), set2.Length()) {
+		t.Errorf("SizeHint() = %d, %d; want %d, %d", lower, upper, 0, min(set1.Length(), set2.Length()))
+	}
+
+	if got := joinAll(iterator); !slices.Equal(got, want) {
+		t.Errorf("results = %v; want %v", got, want)
+	}
+
+	if !iterator.Explain().Merge {
+		t.Errorf("Explain() = %v; want merge", iterator.Explain())
+	}
+}
+
+func TestJoin_MergeRequiresComparableLengths(t *testing.T) {
+	tests := []struct {
+		name       string
+		set1, set2 *sparseset.Set[int]
+	}{
+		{"Small", newBenchmarkSet(100, 1), newBenchmarkSet(100, 2)},
+		{"Unbalanced", newBenchmarkSet(1024, 1), newBenchmarkSet(100000, 1)},
+	}
+
+	for _, test := range tests {
+		iterator := sparseset.Join(test.set1, test.set2)
+		want := joinAll(sparseset.JoinDrivenBy(1, test.set1, test.set2))
+		if got := joinAll(iterator); !slices.Equal(got, want) {
+			t.Errorf("%s: results = %v; want %v", test.name, got, want)
+		}
+
+		if stats := iterator.Explain(); stats.Merge || stats.Driver != 1 {
+			t.Errorf("%s: Explain() = %v; want driver %d", test.name, stats, 1)
+		}
+	}
+}
+
+func TestJoin_AdaptiveNeverMerges(t *testing.T) {
+	set1 := newBenchmarkSet(2000, 1)
+	set2 := newBenchmarkSet(2000, 2)
+
+	if stats := sparseset.Join(set1, set2).Explain(); !stats.Merge {
+		t.Errorf("Explain() = %v; want merge", stats)
+	}
+
+	if stats := sparseset.Join(set1, set2).Adaptive().Explain(); stats.Merge || !stats.Adaptive {
+		t.Errorf("Adaptive().Explain() = %v; want adaptive driver", stats)
+	}
+}
+
+// BenchmarkJoin_Lookups joins large sets that are not sorted by key, therefore
+// the join looks up the keys of the driver in the index of the other set.
+func BenchmarkJoin_Lookups(b *testing.B) {
+	set1 := newUnsortedSet(100000, 1)
+	set2 := newUnsortedSet(100000, 1)
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	sum := 0
+	for n := 0; n < b.N; n++ {
+		for iterator := sparseset.Join(set1, set2); ; {
+			_, a, b, ok := iterator.Next()
+			if !ok {
+				break
+			}
+			sum += *a + *b
+		}
+	}
+}
+
+func BenchmarkJoin_Merge(b *testing.B) {
+	set1 := newBenchmarkSet(10000, 1)
+	set2 := newBenchmarkSet(10000, 2)
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	sum := 0
+	for n := 0; n < b.N; n++ {
+		for iterator := sparseset.Join(set1, set2); ; {
+			_, a, b, ok := iterator.Next()
+			if !ok {
+				break
+			}
+			sum += *a + *b
+		}
+	}
+}
@@ -0,0 +1,66 @@
+package sparseset
+
+// KeySet is a KeySetOf with int keys.
+type KeySet = KeySetOf[int]
+
+// KeySetOf is a sparse set without a value store. It is useful for tags (or
+// markers), i.e., when only the presence of a key is relevant.
+//
+// KeySets can be used to filter the iteration of sets and joins (see
+// Iterator.With() and JoinIterator.With()).
+//
+// This is thread-compatible (see thread-safety notes on Set).
+type KeySetOf[K Key] struct {
+	// Stores positions (pos) by key and keys by position.
+	sparse[K]
+}
+
+// Add inserts key into the set. Returns false if the key is out of range.
+func (s *KeySetOf[K]) Add(key K) bool {
+	_, _, ok := s.add(key)
+	return ok
+}
+
+func (s *KeySetOf[K]) Remove(key K) { s.remove(key) }
+
+func NewKeySet(defaultPageSize, nullKey int) *KeySet {
+	return NewKeySetOf(defaultPageSize, nullKey)
+}
+
+// NewKeySetOf returns a set whose keys are of type K and less than nullKey.
+func NewKeySetOf[K Key](defaultPageSize int, nullKey K) *KeySetOf[K] {
+	return &KeySetOf[K]{newSparse(newPositionIndex[K](defaultPageSize, nullPosition(nullKey), IndexWidthInt, PagedArrayOptions[int]{}), nullKey)}
+}
+
+func hasAll[K Key](key K, tags []*KeySetOf[K]) bool {
+	for _, tag := range tags {
+		if !tag.Has(key) {
+			return false
+		}
+	}
+	return true
+}
+
+// With restricts the iterator to the keys that are in all the given tags.
+// Returns the iterator itself.
+func (i *IteratorOf[K, A]) With(tags ...*KeySetOf[K]) *IteratorOf[K, A] {
+	return i.Filter(func(key K, _ *A) bool { return hasAll(key, tags) })
+}
+
+// With restricts the iterator to the keys that are in all the given tags.
+// Returns the iterator itself.
+func (i *JoinIteratorOf[K, A, B]) With(tags ...*KeySetOf[K]) *JoinIteratorOf[K, A, B] {
+	return i.Filter(func(key K, _ *A, _ *B) bool { return hasAll(key, tags) })
+}
+
+// With restricts the iterator to the keys that are in all the given tags.
+// Returns the iterator itself.
+func (i *Join3IteratorOf[K, A, B, C]) With(tags ...*KeySetOf[K]) *Join3IteratorOf[K, A, B, C] {
+	return i.Filter(func(key K, _ *A, _ *B, _ *C) bool { return hasAll(key, tags) })
+}
+
+// With restricts the iterator to the keys that are in all the given tags.
+// Returns the iterator itself.
+func (i *Join4IteratorOf[K, A, B, C, D]) With(tags ...*KeySetOf[K]) *Join4IteratorOf[K, A, B, C, D] {
+	return i.Filter(func(key K, _ *A, _ *B, _ *C, _ *D) bool { return hasAll(key, tags) })
+}
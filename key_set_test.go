@@ -0,0 +1,124 @@
+package sparseset_test
+
+import (
+	"cmp"
+	"testing"
+
+	"github.com/jabolopes/go-sparseset"
+	"golang.org/x/exp/slices"
+)
+
+func TestKeySet(t *testing.T) {
+	set := sparseset.NewKeySet(1<<10, 1<<20)
+
+	for _, key := range []int{10, 20, 30, 20} {
+		if !set.Add(key) {
+			t.Errorf("Add(%d) = %v; want %v", key, false, true)
+		}
+	}
+
+	if set.Add(-1) || set.Add(1<<20) {
+		t.Errorf("Add() of out of range key = %v; want %v", true, false)
+	}
+
+	if got := set.Length(); got != 3 {
+		t.Errorf("Length() = %d; want %d", got, 3)
+	}
+
+	set.Remove(10)
+	set.Remove(40)
+
+	if got, want := set.Keys(), []int{30, 20}; !slices.Equal(got, want) {
+		t.Errorf("Keys() = %v; want %v", got, want)
+	}
+
+	for key, want := range map[int]bool{10: false, 20: true, 30: true, 40: false, -1: false} {
+		if got := set.Has(key); got != want {
+			t.Errorf("Has(%d) = %v; want %v", key, got, want)
+		}
+	}
+}
+
+func TestIterator_With(t *testing.T) {
+	set := sparseset.New[int](1<<10, 1<<20)
+	selected := sparseset.NewKeySet(1<<10, 1<<20)
+	visible := sparseset.NewKeySet(1<<10, 1<<20)
+
+	want := []iterateResult[int]{}
+	for i := 0; i < 20; i++ {
+		*set.Add(i) = i * 10
+
+		if i%2 == 0 {
+			selected.Add(i)
+		}
+		if i%3 == 0 {
+			visible.Add(i)
+		}
+		if i%6 == 0 {
+			want = append(want, iterateResult[int]{i, i * 10, true})
+		}
+	}
+
+	got := iterateAll(sparseset.Iterate(set).With(selected, visible))
+	slices.SortFunc(got, func(r1, r2 iterateResult[int]) int { return cmp.Compare(r1.key, r2.key) })
+
+	if !slices.Equal(got, want) {
+		t.Errorf("results = %v; want %v", got, want)
+	}
+}
+
+func TestJoinIterator_With(t *testing.T) {
+	set1 := sparseset.New[string](1<<10, 1<<20)
+	set2 := sparseset.New[int](1<<10, 1<<20)
+	set3 := sparseset.New[float32](1<<10, 1<<20)
+	set4 := sparseset.New[bool](1<<10, 1<<20)
+	dead := sparseset.NewKeySet(1<<10, 1<<20)
+
+	for i := 0; i < 10; i++ {
+		*set1.Add(i) = "a"
+		*set2.Add(i) = i
+		*set3.Add(i) = 1
+		*set4.Add(i) = true
+	}
+	dead.Add(3)
+	dead.Add(7)
+	dead.Add(11)
+
+	want := []int{3, 7}
+
+	t.Run("Join", func(t *testing.T) {
+		got := []int{}
+		for _, result := range joinAll(sparseset.Join(set1, set2).With(dead)) {
+			got = append(got, result.key)
+		}
+		slices.Sort(got)
+
+		if !slices.Equal(got, want) {
+			t.Errorf("keys = %v; want %v", got, want)
+		}
+	})
+
+	t.Run("Join3", func(t *testing.T) {
+		got := []int{}
+		for _, result := range join3All(sparseset.Join3(set1, set2, set3).With(dead)) {
+			got = append(got, result.key)
+		}
+		slices.Sort(got)
+
+		if !slices.Equal(got, want) {
+			t.Errorf("keys = %v; want %v", got, want)
+		}
+	})
+
+	t.Run("Join4", func(t *testing.T) {
+		got := []int{}
+		for _, result := range join4All(sparseset.Join4(set1, set2, set3, set4).With(dead)) {
+			got = append(got, result.key)
+		}
+		slices.Sort(got)
+
+		if !slices.Equal(got, want) {
+			t.Errorf("keys = %v; want %v", got, want)
+		}
+	})
+}
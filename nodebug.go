@@ -0,0 +1,6 @@
+//go:build !sparsesetdebug
+
+package sparseset
+
+// debug is true in debug builds (see debug.go).
+const debug = false
@@ -0,0 +1,7 @@
+//go:build !sparsesetdebug
+
+package sparseset_test
+
+// debugBuild is true if the tests are built with the sparsesetdebug build tag
+// (see debug_test.go).
+const debugBuild = false
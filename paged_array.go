@@ -1,132 +1,490 @@
 package sparseset
 
 import (
-	"sync"
-
 	"golang.org/x/exp/constraints"
 )
 
 type page2[Value any] struct {
-	values    []Value
+	values []Value
+	// Bit i is set if values[i] holds a value. This is nil in the arrays whose
+	// slots without values hold the null value (see PagedArrayOf.isNull).
+	present   []uint64
 	numValues int
 }
 
-type PagedArray[Value constraints.Ordered] struct {
-	pool      *sync.Pool
+func (p *page2[Value]) has(pageOffset uint64) bool {
+	return p.present[pageOffset/64]&(1<<(pageOffset%64)) != 0
+}
+
+// Key is the constraint of the types of keys (indices) of sets and paged
+// arrays, e.g., int, uint32, or a custom ID type such as 'type EntityID
+// uint32'. Keys are non-negative.
+type Key interface {
+	constraints.Integer
+}
+
+// PagedArray is a PagedArrayOf with int keys.
+type PagedArray[Value any] = PagedArrayOf[int, Value]
+
+// PagedArrayOf is a sparse array of values indexed by keys of type K. The
+// array is divided into pages of fixed size that are only allocated when they
+// hold values.
+//
+// The arrays constructed with an ordered value type (e.g., NewPagedArray())
+// ignore the values that are not less than the null value, therefore the null
+// value is a sentinel that is stored in the slots without values. The arrays
+// constructed with NewPagedArrayOfAny() record which slots of each page hold
+// values instead, therefore the null value is not a sentinel and the values can
+// be of any type.
+//
+// This is thread-compatible.
+type PagedArrayOf[K Key, Value any] struct {
+	allocator PageAllocator[Value]
 	pages     []page2[Value]
 	pageSize  int
 	nullValue Value
-	length    int
+	// If not nil, Set() ignores the values for which this returns false.
+	isValid func(Value) bool
+	// If not nil, the slots without values hold the null value and this returns
+	// true for the null value. Otherwise, the pages record which slots hold
+	// values (see page2.present).
+	isNull func(Value) bool
+	length int
+	// Number of pages obtained from the allocator that were reused (hits) or
+	// newly allocated (misses).
+	poolHits   int
+	poolMisses int
+	// Pages whose number is greater than or equal to maxLinearPages are stored in
+	// the directory instead of pages. If 0, all pages are stored in pages.
+	maxLinearPages uint64
+	directory      radixDirectory[Value]
 }
 
-func (a *PagedArray[Value]) NullValue() Value { return a.nullValue }
-func (a *PagedArray[Value]) Length() int      { return a.length }
+// PagedArrayOptions are the options of a PagedArray.
+type PagedArrayOptions[Value any] struct {
+	// If not nil, allocates the pages of the array instead of a per-array pool.
+	// The page size of the allocator is used instead of the given page size.
+	Allocator PageAllocator[Value]
+	// If positive, only the first MaxLinearPages pages are stored in a slice
+	// indexed by page number, and the remaining pages are stored in a radix tree
+	// that only allocates nodes along the paths of the pages with values. This
+	// supports huge and sparse key spaces (e.g., hashed 64-bit keys) without
+	// allocating a slice for all pages up to the highest key. If 0, all pages are
+	// stored in the slice.
+	MaxLinearPages int
+}
 
-func (a *PagedArray[Value]) Get(index int) Value {
-	if index < 0 {
-		return a.nullValue
+func (a *PagedArrayOf[K, Value]) NullValue() Value { return a.nullValue }
+func (a *PagedArrayOf[K, Value]) Length() int      { return a.length }
+
+// page returns the page number and page offset of a non-negative index.
+func (a *PagedArrayOf[K, Value]) page(index K) (uint64, uint64) {
+	i := uint64(index)
+	pageSize := uint64(a.pageSize)
+	return i / pageSize, i % pageSize
+}
+
+// inDirectory returns true if the page with the given number is stored in the
+// directory.
+func (a *PagedArrayOf[K, Value]) inDirectory(pageNum uint64) bool {
+	return a.maxLinearPages > 0 && pageNum >= a.maxLinearPages
+}
+
+// lookup returns the page with the given number or nil if the page does not
+// exist.
+func (a *PagedArrayOf[K, Value]) lookup(pageNum uint64) *page2[Value] {
+	if pageNum < uint64(len(a.pages)) {
+		return &a.pages[pageNum]
+	}
+
+	if a.inDirectory(pageNum) {
+		return a.directory.get(pageNum - a.maxLinearPages)
 	}
 
-	pageNum := index / a.pageSize
-	pageOffset := index % a.pageSize
+	return nil
+}
 
-	if pageNum >= len(a.pages) {
+func (a *PagedArrayOf[K, Value]) Get(index K) Value {
+	if value, ok := a.getLinear(index); ok {
+		return value
+	}
+
+	if index < 0 {
 		return a.nullValue
 	}
 
-	page := &a.pages[pageNum]
-	if page.numValues == 0 {
+	pageNum, pageOffset := a.page(index)
+
+	page := a.lookup(pageNum)
+	if page == nil || page.numValues == 0 || (a.isNull == nil && !page.has(pageOffset)) {
 		return a.nullValue
 	}
 
 	return page.values[pageOffset]
 }
 
-func (a *PagedArray[Value]) Set(index int, value Value) {
-	if index < 0 || value >= a.nullValue {
+// getLinear returns the value at index and true if the index is in the slice
+// of pages of an array whose slots without values hold the null value.
+// Otherwise, returns false and the value must be obtained with Get(). This is
+// the fast path of Get(), which is small enough to be inlined in the lookups of
+// sets.
+func (a *PagedArrayOf[K, Value]) getLinear(index K) (Value, bool) {
+	if pageNum, pageOffset := a.page(index); index >= 0 && pageNum < uint64(len(a.pages)) && a.isNull != nil {
+		if values := a.pages[pageNum].values; values != nil {
+			return values[pageOffset], true
+		}
+		return a.nullValue, true
+	}
+
+	var zero Value
+	return zero, false
+}
+
+// Lookup returns the value at index and true, or the null value and false if
+// there is no value at index.
+func (a *PagedArrayOf[K, Value]) Lookup(index K) (Value, bool) {
+	if index < 0 {
+		return a.nullValue, false
+	}
+
+	pageNum, pageOffset := a.page(index)
+
+	page := a.lookup(pageNum)
+	if page == nil || page.numValues == 0 || !a.hasSlot(page, pageOffset) {
+		return a.nullValue, false
+	}
+
+	return page.values[pageOffset], true
+}
+
+// Set stores the value at index. If the index is negative or the value is
+// invalid (see PagedArrayOf), this does nothing, or panics in debug builds (see
+// TrySet()).
+func (a *PagedArrayOf[K, Value]) Set(index K, value Value) {
+	if index < 0 || (a.isValid != nil && !a.isValid(value)) {
+		if debug {
+			panic(a.checkSet(index, value))
+		}
+		return
+	}
+
+	pageNum, pageOffset := a.page(index)
+	a.setSlot(a.pageFor(pageNum), pageOffset, value)
+}
+
+// setRun sets the n consecutive indices starting at index to the values
+// returned by value(0), ..., value(n-1). This is faster than calling Set() for
+// each index because it looks up each page only once. The values must be
+// valid.
+func (a *PagedArrayOf[K, Value]) setRun(index K, n int, value func(int) Value) {
+	if index < 0 {
 		return
 	}
 
-	pageNum := index / a.pageSize
-	pageOffset := index % a.pageSize
+	for i := 0; i < n; {
+		pageNum, pageOffset := a.page(index + K(i))
+		page := a.pageFor(pageNum)
+
+		for ; pageOffset < uint64(a.pageSize) && i < n; pageOffset, i = pageOffset+1, i+1 {
+			a.setSlot(page, pageOffset, value(i))
+		}
+	}
+}
+
+// hasSlot returns true if the slot of the page at pageOffset holds a value.
+func (a *PagedArrayOf[K, Value]) hasSlot(page *page2[Value], pageOffset uint64) bool {
+	if a.isNull != nil {
+		return !a.isNull(page.values[pageOffset])
+	}
+	return page.has(pageOffset)
+}
+
+func (a *PagedArrayOf[K, Value]) setSlot(page *page2[Value], pageOffset uint64, value Value) {
+	if !a.hasSlot(page, pageOffset) {
+		if a.isNull == nil {
+			page.present[pageOffset/64] |= 1 << (pageOffset % 64)
+		}
+		page.numValues++
+		a.length++
+	}
+	page.values[pageOffset] = value
+}
 
-	if pageNum >= len(a.pages) {
-		// Allocate pages between len(a.pages) and a.pages[pageNum].
-		if diff := pageNum - len(a.pages); diff >= 0 {
+// pageFor returns the page with the given number, allocating and initializing
+// it if necessary.
+func (a *PagedArrayOf[K, Value]) pageFor(pageNum uint64) *page2[Value] {
+	var page *page2[Value]
+	if a.inDirectory(pageNum) {
+		page = a.directory.getOrCreate(pageNum - a.maxLinearPages)
+	} else {
+		if pageNum >= uint64(len(a.pages)) {
+			// Allocate pages between len(a.pages) and a.pages[pageNum].
+			diff := pageNum - uint64(len(a.pages))
 			a.pages = append(a.pages, make([]page2[Value], diff+1)...)
 		}
+
+		page = &a.pages[pageNum]
 	}
 
-	page := &a.pages[pageNum]
 	if page.values == nil {
-		// initialize the page.
-		page.values = a.pool.Get().([]Value)
-		page.numValues = 0
+		a.allocatePage(page)
+	}
 
+	return page
+}
+
+// allocatePage initializes a page without values.
+func (a *PagedArrayOf[K, Value]) allocatePage(page *page2[Value]) {
+	var reused bool
+	page.values, reused = a.allocator.Allocate()
+	if reused {
+		a.poolHits++
+	} else {
+		a.poolMisses++
+	}
+	page.numValues = 0
+
+	if a.isNull != nil {
 		for i := range page.values {
 			page.values[i] = a.nullValue
 		}
+	} else if page.present == nil {
+		page.present = make([]uint64, (a.pageSize+63)/64)
 	}
+}
 
-	if page.values[pageOffset] == a.nullValue {
-		page.numValues++
-		a.length++
+// TrySet is like Set but returns ErrKeyOutOfRange if the index is negative and
+// ErrValueOutOfRange if the value is invalid.
+func (a *PagedArrayOf[K, Value]) TrySet(index K, value Value) error {
+	if err := a.checkSet(index, value); err != nil {
+		return err
 	}
-	page.values[pageOffset] = value
+
+	a.Set(index, value)
+	return nil
 }
 
-// TODO: Reclaim pages at the end of the array that are empty.
-func (a *PagedArray[Value]) Unset(index int) {
+func (a *PagedArrayOf[K, Value]) checkSet(index K, value Value) error {
 	if index < 0 {
-		return
+		return keyError(ErrKeyOutOfRange, index)
+	}
+
+	if a.isValid != nil && !a.isValid(value) {
+		return keyError(ErrValueOutOfRange, value)
 	}
 
-	pageNum := index / a.pageSize
-	pageOffset := index % a.pageSize
+	return nil
+}
 
-	if pageNum >= len(a.pages) {
+// Unset removes the value at index. Pages that become empty are returned to the
+// allocator, but the trailing empty pages are only reclaimed by Compact().
+func (a *PagedArrayOf[K, Value]) Unset(index K) {
+	if index < 0 {
 		return
 	}
 
-	page := &a.pages[pageNum]
-	if page.values == nil || page.values[pageOffset] == a.nullValue {
+	pageNum, pageOffset := a.page(index)
+
+	page := a.lookup(pageNum)
+	if page == nil || page.values == nil || !a.hasSlot(page, pageOffset) {
 		return
 	}
 
 	page.numValues--
 	a.length--
-	page.values[pageOffset] = a.nullValue
+
+	if a.isNull != nil {
+		page.values[pageOffset] = a.nullValue
+	} else {
+		page.present[pageOffset/64] &^= 1 << (pageOffset % 64)
+
+		// Facilitate GC.
+		var defaultValue Value
+		page.values[pageOffset] = defaultValue
+	}
 
 	if page.numValues <= 0 {
-		var values []Value
-		values, page.values = page.values, nil
-		a.pool.Put(values)
+		a.freePage(page)
+
+		if a.inDirectory(pageNum) {
+			a.directory.prune(pageNum - a.maxLinearPages)
+		}
 	}
 }
 
-func (a *PagedArray[Value]) Clear() {
-	for _, page := range a.pages {
-		if page.values != nil {
-			var values []Value
-			values, page.values = page.values, nil
-			a.pool.Put(values)
+// freePage returns the values of page to the allocator.
+func (a *PagedArrayOf[K, Value]) freePage(page *page2[Value]) {
+	var values []Value
+	values, page.values = page.values, nil
+	clear(values)
+	a.allocator.Free(values)
+
+	clear(page.present)
+	page.numValues = 0
+}
+
+// Clear unsets all the values of the array and returns all the pages to the
+// allocator. This does not release the capacity of the array (see
+// ShrinkToFit()).
+func (a *PagedArrayOf[K, Value]) Clear() {
+	for pageNum := range a.pages {
+		if page := &a.pages[pageNum]; page.values != nil {
+			a.freePage(page)
 		}
 	}
 
-	a.pages = nil
+	a.directory.forEach(func(_ uint64, page *page2[Value]) {
+		if page.values != nil {
+			a.freePage(page)
+		}
+	})
+
+	a.pages = a.pages[:0]
+	a.directory = radixDirectory[Value]{}
 	a.length = 0
 }
 
+// clone returns a copy of the array whose pages are allocated by the same
+// allocator. The values are copied by assignment.
+func (a *PagedArrayOf[K, Value]) clone() *PagedArrayOf[K, Value] {
+	clone := *a
+	clone.pages = make([]page2[Value], len(a.pages))
+	clone.poolHits = 0
+	clone.poolMisses = 0
+	clone.directory = radixDirectory[Value]{}
+
+	for pageNum := range a.pages {
+		clone.clonePage(&clone.pages[pageNum], &a.pages[pageNum])
+	}
+
+	a.directory.forEach(func(pageNum uint64, page *page2[Value]) {
+		clone.clonePage(clone.directory.getOrCreate(pageNum), page)
+	})
+
+	return &clone
+}
+
+func (a *PagedArrayOf[K, Value]) clonePage(dst, src *page2[Value]) {
+	if src.values == nil {
+		return
+	}
+
+	a.allocatePage(dst)
+	copy(dst.values, src.values)
+	copy(dst.present, src.present)
+	dst.numValues = src.numValues
+}
+
+// forEach calls f with the index and value of every value in the array.
+func (a *PagedArrayOf[K, Value]) forEach(f func(K, Value)) {
+	forEachValue := func(pageNum uint64, page *page2[Value]) {
+		if page.values == nil {
+			return
+		}
+
+		for pageOffset, value := range page.values {
+			if a.hasSlot(page, uint64(pageOffset)) {
+				f(K(pageNum*uint64(a.pageSize)+uint64(pageOffset)), value)
+			}
+		}
+	}
+
+	for pageNum := range a.pages {
+		forEachValue(uint64(pageNum), &a.pages[pageNum])
+	}
+
+	a.directory.forEach(func(pageNum uint64, page *page2[Value]) {
+		forEachValue(pageNum+a.maxLinearPages, page)
+	})
+}
+
+// Compact reclaims the empty pages at the end of the array. This does not
+// release the capacity of the array (see ShrinkToFit()).
+func (a *PagedArrayOf[K, Value]) Compact() {
+	last := len(a.pages)
+	for last > 0 && a.pages[last-1].values == nil {
+		last--
+	}
+
+	a.pages = a.pages[:last]
+}
+
+// ShrinkToFit reclaims the empty pages at the end of the array and releases
+// the excess capacity of the array.
+func (a *PagedArrayOf[K, Value]) ShrinkToFit() {
+	a.Compact()
+	a.pages = shrink(a.pages)
+}
+
+// shrink returns a slice with the same elements as s and without excess
+// capacity.
+func shrink[T any](s []T) []T { return shrinkTo(s, len(s)) }
+
+// shrinkTo reallocates s with the given capacity, which must not be less than
+// the length of s, if s has more capacity than that.
+func shrinkTo[T any](s []T, capacity int) []T {
+	if cap(s) <= capacity {
+		return s
+	}
+
+	shrunk := make([]T, len(s), capacity)
+	copy(shrunk, s)
+	return shrunk
+}
+
 func NewPagedArray[Value constraints.Ordered](pageSize int, nullValue Value) *PagedArray[Value] {
-	return &PagedArray[Value]{
-		&sync.Pool{
-			New: func() any {
-				return make([]Value, pageSize)
-			},
-		},
+	return NewPagedArrayOf[int](pageSize, nullValue)
+}
+
+// NewPagedArrayWithAllocator returns a PagedArray whose pages are allocated by
+// the given allocator. The page size of the array is the page size of the
+// allocator.
+func NewPagedArrayWithAllocator[Value constraints.Ordered](allocator PageAllocator[Value], nullValue Value) *PagedArray[Value] {
+	return NewPagedArrayOfWithAllocator[int](allocator, nullValue)
+}
+
+func NewPagedArrayWithOptions[Value constraints.Ordered](pageSize int, nullValue Value, options PagedArrayOptions[Value]) *PagedArray[Value] {
+	return NewPagedArrayOfWithOptions[int](pageSize, nullValue, options)
+}
+
+func NewPagedArrayOf[K Key, Value constraints.Ordered](pageSize int, nullValue Value) *PagedArrayOf[K, Value] {
+	return NewPagedArrayOfWithOptions[K](pageSize, nullValue, PagedArrayOptions[Value]{})
+}
+
+// NewPagedArrayOfWithAllocator is like NewPagedArrayWithAllocator but for keys
+// of type K.
+func NewPagedArrayOfWithAllocator[K Key, Value constraints.Ordered](allocator PageAllocator[Value], nullValue Value) *PagedArrayOf[K, Value] {
+	return NewPagedArrayOfWithOptions[K](allocator.PageSize(), nullValue, PagedArrayOptions[Value]{Allocator: allocator})
+}
+
+// NewPagedArrayOfWithOptions returns an array that ignores the values that are
+// not less than the null value, which is stored in the slots without values.
+func NewPagedArrayOfWithOptions[K Key, Value constraints.Ordered](pageSize int, nullValue Value, options PagedArrayOptions[Value]) *PagedArrayOf[K, Value] {
+	array := NewPagedArrayOfAny[K](pageSize, nullValue, options)
+	array.isValid = func(value Value) bool { return value < nullValue }
+	array.isNull = func(value Value) bool { return value == nullValue }
+	return array
+}
+
+// NewPagedArrayOfAny returns an array whose values can be of any type. All
+// values are valid, including the null value, which is only returned by Get()
+// for the indices without values (see Lookup()).
+func NewPagedArrayOfAny[K Key, Value any](pageSize int, nullValue Value, options PagedArrayOptions[Value]) *PagedArrayOf[K, Value] {
+	if options.Allocator == nil {
+		options.Allocator = NewPoolAllocator[Value](pageSize)
+	}
+
+	return &PagedArrayOf[K, Value]{
+		options.Allocator,
 		nil, /* pages */
-		pageSize,
+		options.Allocator.PageSize(),
 		nullValue,
-		0, /* length */
+		nil, /* isValid */
+		nil, /* isNull */
+		0,   /* length */
+		0,   /* poolHits */
+		0,   /* poolMisses */
+		uint64(max(options.MaxLinearPages, 0)),
+		radixDirectory[Value]{},
 	}
 }
@@ -1,6 +1,7 @@
 package sparseset_test
 
 import (
+	"errors"
 	"math/rand"
 	"testing"
 	"testing/quick"
@@ -94,6 +95,9 @@ func TestSet_Rand(t *testing.T) {
 
 		if value < nullValue {
 			set[index] = value
+		} else if debugBuild {
+			// Invalid values panic in debug builds.
+			continue
 		}
 
 		array.Set(index, value)
@@ -142,6 +146,9 @@ func TestUnset_Rand(t *testing.T) {
 
 		if value < nullValue {
 			set[index] = value
+		} else if debugBuild {
+			// Invalid values panic in debug builds.
+			continue
 		}
 
 		array.Set(index, value)
@@ -210,3 +217,278 @@ func TestGet_Quick(t *testing.T) {
 		t.Error(err)
 	}
 }
+
+func TestCompact(t *testing.T) {
+	const pageSize = 5
+	const nullValue = 100
+
+	for _, shrink := range []bool{false, true} {
+		array := sparseset.NewPagedArray(pageSize, nullValue)
+
+		for i := 0; i < 30; i++ {
+			array.Set(i, i)
+		}
+
+		for i := 7; i < 30; i++ {
+			array.Unset(i)
+		}
+
+		if shrink {
+			array.ShrinkToFit()
+		} else {
+			array.Compact()
+		}
+
+		for i := 0; i < 30; i++ {
+			want := i
+			if i >= 7 {
+				want = nullValue
+			}
+
+			if got := array.Get(i); got != want {
+				t.Errorf("Get(%d) = %v; want %v", i, got, want)
+			}
+		}
+
+		if got := array.Length(); got != 7 {
+			t.Errorf("Length() = %v; want %v", got, 7)
+		}
+
+		array.Set(29, 29)
+		if got := array.Get(29); got != 29 {
+			t.Errorf("Get(%d) = %v; want %v", 29, got, 29)
+		}
+	}
+}
+
+func TestPagedArrayOf(t *testing.T) {
+	const pageSize = 1 << 10
+	const nullValue = int(1e6)
+	array := sparseset.NewPagedArrayOf[uint64](pageSize, nullValue)
+
+	array.Set(1<<20, 1)
+	array.Set(3, 2)
+
+	if got := array.Get(1 << 20); got != 1 {
+		t.Errorf("Get(%d) = %v; want %v", 1<<20, got, 1)
+	}
+
+	if got := array.Get(1<<63 + 3); got != nullValue {
+		t.Errorf("Get(%d) = %v; want %v", uint64(1<<63+3), got, nullValue)
+	}
+
+	array.Unset(3)
+
+	if got := array.Length(); got != 1 {
+		t.Errorf("Length() = %v; want %v", got, 1)
+	}
+}
+
+func TestPagedArrayOf_MaxLinearPages(t *testing.T) {
+	const pageSize = 1 << 10
+	const nullValue = int(1e6)
+	options := sparseset.PagedArrayOptions[int]{MaxLinearPages: 4}
+	array := sparseset.NewPagedArrayOfWithOptions[uint64](pageSize, nullValue, options)
+
+	indices := []uint64{3, 4*pageSize - 1, 4 * pageSize, 1 << 40, 1<<40 + 1, 1<<63 + 5, 1<<64 - 1}
+	for i, index := range indices {
+		array.Set(index, i)
+	}
+
+	for i, index := range indices {
+		if got := array.Get(index); got != i {
+			t.Errorf("Get(%d) = %v; want %v", index, got, i)
+		}
+	}
+
+	if got := array.Get(1<<40 + pageSize); got != nullValue {
+		t.Errorf("Get(%d) = %v; want %v", uint64(1<<40+pageSize), got, nullValue)
+	}
+
+	stats := array.Stats()
+	if stats.Pages != 4+4 || stats.LivePages != 2+4 {
+		t.Errorf("Pages, LivePages = %d, %d; want %d, %d", stats.Pages, stats.LivePages, 8, 6)
+	}
+	if stats.DirectoryNodes == 0 || stats.DirectoryNodes > 3*8 {
+		t.Errorf("DirectoryNodes = %d; want between %d and %d", stats.DirectoryNodes, 1, 3*8)
+	}
+
+	for _, index := range indices[2:] {
+		array.Unset(index)
+	}
+
+	if got := array.Length(); got != 2 {
+		t.Errorf("Length() = %v; want %v", got, 2)
+	}
+
+	if got := array.Stats().DirectoryNodes; got != 0 {
+		t.Errorf("DirectoryNodes = %d; want %d", got, 0)
+	}
+
+	array.Set(1<<50, 1)
+	array.Clear()
+
+	for _, index := range append(indices, 1<<50) {
+		if got := array.Get(index); got != nullValue {
+			t.Errorf("Get(%d) = %v; want %v", index, got, nullValue)
+		}
+	}
+}
+
+func TestPagedArrayOf_MaxLinearPages_HighFirstPage(t *testing.T) {
+	tests := []struct {
+		index int
+		nodes int
+	}{
+		// Page 1<<34 - 4 of the directory is covered by 5 levels.
+		{1 << 40, 5},
+		// Page 1<<57 - 5 of the directory is covered by 8 levels.
+		{1<<63 - 1, 8},
+	}
+
+	for _, test := range tests {
+		options := sparseset.PagedArrayOptions[int]{MaxLinearPages: 4}
+		array := sparseset.NewPagedArrayOfAny[int, int](64, -1, options)
+
+		for i := 0; i < 2; i++ {
+			array.Set(test.index, 1)
+			if got := array.Stats().DirectoryNodes; got != test.nodes {
+				t.Errorf("DirectoryNodes after Set(%d) = %d; want %d", test.index, got, test.nodes)
+			}
+
+			array.Unset(test.index)
+			if got := array.Stats().DirectoryNodes; got != 0 {
+				t.Errorf("DirectoryNodes after Unset(%d) = %d; want %d", test.index, got, 0)
+			}
+		}
+	}
+}
+
+func TestPagedArrayOf_MaxLinearPages_Rand(t *testing.T) {
+	const n = 1000
+
+	const pageSize = 1 << 4
+	const nullValue = int(1e6)
+	options := sparseset.PagedArrayOptions[int]{MaxLinearPages: 1}
+	array := sparseset.NewPagedArrayOfWithOptions[uint64](pageSize, nullValue, options)
+
+	set := map[uint64]int{}
+	for len(set) < n {
+		// Cluster the indices to share directory nodes and pages.
+		index := rand.Uint64()>>rand.Intn(64) | uint64(rand.Intn(4))
+		value := rand.Intn(nullValue)
+
+		set[index] = value
+		array.Set(index, value)
+	}
+
+	i := 0
+	for index, want := range set {
+		if got := array.Get(index); got != want {
+			t.Errorf("Get(%d) = %v; want %v", index, got, want)
+		}
+
+		if i%2 == 0 {
+			array.Unset(index)
+			delete(set, index)
+		}
+		i++
+	}
+
+	if got := array.Length(); got != len(set) {
+		t.Errorf("Length() = %v; want %v", got, len(set))
+	}
+
+	for index, want := range set {
+		if got := array.Get(index); got != want {
+			t.Errorf("Get(%d) = %v; want %v", index, got, want)
+		}
+	}
+}
+
+func TestTrySet(t *testing.T) {
+	const pageSize = 1 << 10
+	const nullValue = int(1e6)
+	array := sparseset.NewPagedArray(pageSize, nullValue)
+
+	if err := array.TrySet(10, 1); err != nil {
+		t.Errorf("TrySet(%d, %d) = %v; want %v", 10, 1, err, nil)
+	}
+
+	if err := array.TrySet(-1, 1); !errors.Is(err, sparseset.ErrKeyOutOfRange) {
+		t.Errorf("TrySet(%d, %d) = %v; want %v", -1, 1, err, sparseset.ErrKeyOutOfRange)
+	}
+
+	if err := array.TrySet(11, nullValue); !errors.Is(err, sparseset.ErrValueOutOfRange) {
+		t.Errorf("TrySet(%d, %d) = %v; want %v", 11, nullValue, err, sparseset.ErrValueOutOfRange)
+	}
+
+	if got := array.Length(); got != 1 {
+		t.Errorf("Length() = %v; want %v", got, 1)
+	}
+}
+
+func TestNewPagedArrayOfAny(t *testing.T) {
+	type point struct{ x, y float64 }
+
+	const pageSize = 1 << 4
+	array := sparseset.NewPagedArrayOfAny[int](pageSize, point{}, sparseset.PagedArrayOptions[point]{})
+
+	// The null value is a valid value.
+	array.Set(3, point{})
+	array.Set(20, point{1, 2})
+
+	if got, ok := array.Lookup(3); got != (point{}) || !ok {
+		t.Errorf("Lookup(%d) = %v, %v; want %v, %v", 3, got, ok, point{}, true)
+	}
+
+	if got, ok := array.Lookup(20); got != (point{1, 2}) || !ok {
+		t.Errorf("Lookup(%d) = %v, %v; want %v, %v", 20, got, ok, point{1, 2}, true)
+	}
+
+	if got, ok := array.Lookup(4); got != (point{}) || ok {
+		t.Errorf("Lookup(%d) = %v, %v; want %v, %v", 4, got, ok, point{}, false)
+	}
+
+	if got := array.Length(); got != 2 {
+		t.Errorf("Length() = %v; want %v", got, 2)
+	}
+
+	array.Unset(3)
+
+	if _, ok := array.Lookup(3); ok {
+		t.Errorf("Lookup(%d) = _, %v; want _, %v", 3, ok, false)
+	}
+
+	if got := array.Length(); got != 1 {
+		t.Errorf("Length() = %v; want %v", got, 1)
+	}
+}
+
+func TestPagedArrayOf_PresenceBitmap(t *testing.T) {
+	const pageSize = 1 << 6
+	ordered := sparseset.NewPagedArrayOf[int](pageSize, 100)
+	anyValue := sparseset.NewPagedArrayOfAny[int](pageSize, 100, sparseset.PagedArrayOptions[int]{})
+
+	for i := 0; i < 2; i++ {
+		ordered.Set(1, 5)
+		anyValue.Set(1, 5)
+
+		// Only the arrays of any values record which slots hold values, which
+		// takes 1 word per 64 slots.
+		if got, want := anyValue.Stats().Bytes-ordered.Stats().Bytes, 8; got != want {
+			t.Errorf("Stats().Bytes difference = %d; want %d", got, want)
+		}
+
+		// The slots without values hold the null value, also in the pages that
+		// are reused from the pool.
+		for _, array := range []*sparseset.PagedArray[int]{ordered, anyValue} {
+			if got, ok := array.Lookup(2); got != 100 || ok {
+				t.Errorf("Lookup(%d) = %v, %v; want %v, %v", 2, got, ok, 100, false)
+			}
+		}
+
+		ordered.Unset(1)
+		anyValue.Unset(1)
+	}
+}
@@ -0,0 +1,248 @@
+package sparseset
+
+import (
+	"fmt"
+	"reflect"
+	"unsafe"
+)
+
+// Without marks the keys that are in the set of values of type T as excluded
+// from a query, e.g., a field `_ Without[Frozen]` (see QueryOf).
+type Without[T any] struct{}
+
+func (Without[T]) excludedType() reflect.Type { return reflect.TypeFor[T]() }
+
+// withoutMarker is implemented by all the Without types.
+type withoutMarker interface {
+	excludedType() reflect.Type
+}
+
+var withoutMarkerType = reflect.TypeFor[withoutMarker]()
+
+// querySet is a set bound to a query without the type of its values.
+type querySet[K Key] struct {
+	keys *sparse[K]
+	// Returns a pointer to the value of the key, if the key is in the set.
+	get func(K) (unsafe.Pointer, bool)
+	// Returns a pointer to the value in the given position.
+	at func(int) unsafe.Pointer
+}
+
+// queryField is a field of the struct of a query.
+type queryField[K Key] struct {
+	name string
+	// Offset of the field in the struct. This is ignored if without is true.
+	offset uintptr
+	// Type of the values of the set of this field, i.e., the element type of the
+	// pointer or the type argument of Without.
+	valueType reflect.Type
+	// If true, the field is nil instead of excluding the key when the key is not
+	// in the set.
+	optional bool
+	// If true, the keys of the set are excluded (see Without).
+	without bool
+	// Set bound to this field (see Bind()).
+	set *querySet[K]
+}
+
+// Query is a QueryOf with int keys.
+type Query[T any] = QueryOf[int, T]
+
+// QueryOf is a join of sets that is described by the struct T, e.g.:
+//
+//	type MoveQuery struct {
+//		Pos *Position
+//		Vel *Velocity `sparseset:"optional"`
+//		_   sparseset.Without[Frozen]
+//	}
+//
+// Each pointer field of T is bound to a set whose values have the type pointed
+// to by the field (see Bind()), and each Without field is bound to a set whose
+// values have its type argument or to a KeySet (see BindKeys()). The query
+// matches the keys that are in the sets of all the pointer fields, except the
+// optional ones, and that are not in the sets of the Without fields. The
+// optional fields are nil if the key is not in their set.
+//
+// The fields of T are inspected via reflection once, by NewQuery(). The
+// iteration does not use reflection.
+type QueryOf[K Key, T any] struct {
+	fields []queryField[K]
+}
+
+// QueryIterator is a QueryIteratorOf with int keys.
+type QueryIterator[T any] = QueryIteratorOf[int, T]
+
+// QueryIteratorOf traverses the keys that match a query (see QueryOf).
+//
+// Like Iterator, this is a plain struct, the zero value is an empty iterator,
+// and an iterator can be reused with Reset().
+type QueryIteratorOf[K Key, T any] struct {
+	query *QueryOf[K, T]
+	// Set that drives the query, which is the smallest set of the fields that
+	// are neither optional nor Without, and its keys.
+	driver *querySet[K]
+	keys   []K
+	// Position of the next key in keys.
+	pos int
+	// The struct returned by Next().
+	value T
+}
+
+// Next returns the next key that matches the query and a pointer to the struct
+// whose fields point to the values of the key. The struct is overwritten by the
+// next call to Next().
+func (i *QueryIteratorOf[K, T]) Next() (K, *T, bool) {
+	base := unsafe.Pointer(&i.value)
+
+next:
+	for i.pos < len(i.keys) {
+		pos, key := i.pos, i.keys[i.pos]
+		i.pos++
+
+		for j := range i.query.fields {
+			field := &i.query.fields[j]
+
+			var value unsafe.Pointer
+			switch {
+			case field.without:
+				if field.set.keys.Has(key) {
+					continue next
+				}
+				continue
+			case field.set == i.driver:
+				value = field.set.at(pos)
+			default:
+				var ok bool
+				if value, ok = field.set.get(key); !ok && !field.optional {
+					continue next
+				}
+			}
+
+			*(*unsafe.Pointer)(unsafe.Add(base, field.offset)) = value
+		}
+
+		return key, &i.value, true
+	}
+
+	return 0, nil, false
+}
+
+// Reset restarts the iterator over the given query (see QueryOf.Iterate()).
+func (i *QueryIteratorOf[K, T]) Reset(query *QueryOf[K, T]) {
+	*i = QueryIteratorOf[K, T]{query: query}
+
+	for j := range query.fields {
+		field := &query.fields[j]
+		if field.set == nil {
+			panic(fmt.Sprintf("sparseset: query field %s of type %v is not bound", field.name, field.valueType))
+		}
+
+		if field.optional || field.without {
+			continue
+		}
+
+		if i.driver == nil || field.set.keys.Length() < i.driver.keys.Length() {
+			i.driver = field.set
+		}
+	}
+	i.keys = i.driver.keys.dense
+}
+
+// Iterate returns an iterator over the keys that match the query. Panics if a
+// field of the query is not bound to a set (see Bind()).
+func (q *QueryOf[K, T]) Iterate() *QueryIteratorOf[K, T] {
+	iterator := &QueryIteratorOf[K, T]{}
+	iterator.Reset(q)
+	return iterator
+}
+
+// Bind binds the set to the fields of the query whose type is a pointer to V or
+// Without[V]. Panics if the query has no such fields.
+func Bind[T, V any, K Key](query *QueryOf[K, T], set *SetOf[K, V]) {
+	binding := &querySet[K]{
+		&set.sparse,
+		func(key K) (unsafe.Pointer, bool) {
+			value, ok := set.Get(key)
+			return unsafe.Pointer(value), ok
+		},
+		func(pos int) unsafe.Pointer { return unsafe.Pointer(&set.store[pos]) },
+	}
+
+	if !query.bind(reflect.TypeFor[V](), binding, false /* withoutOnly */) {
+		panic(fmt.Sprintf("sparseset: query %v has no field of type *%v or Without[%v]", reflect.TypeFor[T](), reflect.TypeFor[V](), reflect.TypeFor[V]()))
+	}
+}
+
+// BindKeys binds the tags to the Without[V] fields of the query, e.g.,
+// BindKeys[Frozen](query, frozen) for a field `_ Without[Frozen]`, where frozen
+// is a KeySet. Since tags have no values, they cannot be bound to pointer
+// fields. Panics if the query has no such fields.
+func BindKeys[V, T any, K Key](query *QueryOf[K, T], tags *KeySetOf[K]) {
+	binding := &querySet[K]{&tags.sparse, nil /* get */, nil /* at */}
+
+	if !query.bind(reflect.TypeFor[V](), binding, true /* withoutOnly */) {
+		panic(fmt.Sprintf("sparseset: query %v has no field of type Without[%v]", reflect.TypeFor[T](), reflect.TypeFor[V]()))
+	}
+}
+
+// bind binds the set to the fields of the query for values of the given type,
+// or only to its Without fields if withoutOnly is true. Returns false if the
+// query has no such fields.
+func (q *QueryOf[K, T]) bind(valueType reflect.Type, binding *querySet[K], withoutOnly bool) bool {
+	bound := false
+	for j := range q.fields {
+		if field := &q.fields[j]; field.valueType == valueType && (field.without || !withoutOnly) {
+			field.set = binding
+			bound = true
+		}
+	}
+	return bound
+}
+
+// NewQuery is like NewQueryOf with int keys.
+func NewQuery[T any]() *Query[T] {
+	return NewQueryOf[int, T]()
+}
+
+// NewQueryOf returns a query described by the struct T (see QueryOf). Panics if
+// T is not a struct, if a field of T is neither a pointer nor Without, or if
+// all the fields are optional or Without.
+func NewQueryOf[K Key, T any]() *QueryOf[K, T] {
+	structType := reflect.TypeFor[T]()
+	if structType.Kind() != reflect.Struct {
+		panic(fmt.Sprintf("sparseset: query type %v is not a struct", structType))
+	}
+
+	query := &QueryOf[K, T]{}
+	required := false
+	for j := 0; j < structType.NumField(); j++ {
+		structField := structType.Field(j)
+		field := queryField[K]{name: structField.Name, offset: structField.Offset}
+
+		switch {
+		case structField.Type.Implements(withoutMarkerType):
+			field.valueType = reflect.Zero(structField.Type).Interface().(withoutMarker).excludedType()
+			field.without = true
+		case structField.Type.Kind() == reflect.Pointer:
+			field.valueType = structField.Type.Elem()
+		default:
+			panic(fmt.Sprintf("sparseset: query field %s has type %v; want a pointer or Without", structField.Name, structField.Type))
+		}
+
+		switch tag := structField.Tag.Get("sparseset"); {
+		case tag == "optional" && !field.without:
+			field.optional = true
+		case tag != "":
+			panic(fmt.Sprintf("sparseset: query field %s has invalid tag %q", structField.Name, tag))
+		}
+
+		required = required || !field.optional && !field.without
+		query.fields = append(query.fields, field)
+	}
+
+	if !required {
+		panic(fmt.Sprintf("sparseset: query %v has no required fields", structType))
+	}
+
+	return query
+}
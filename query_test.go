@@ -0,0 +1,271 @@
+package sparseset_test
+
+import (
+	"testing"
+
+	"github.com/jabolopes/go-sparseset"
+	"golang.org/x/exp/slices"
+)
+
+type Position struct{ X, Y int }
+
+type Velocity struct{ DX, DY int }
+
+type Frozen struct{}
+
+type MoveQuery struct {
+	Pos *Position
+	Vel *Velocity `sparseset:"optional"`
+	_   sparseset.Without[Frozen]
+}
+
+type queryResult struct {
+	key int
+	pos Position
+	vel *Velocity
+}
+
+func queryAll(iterator *sparseset.QueryIterator[MoveQuery]) []queryResult {
+	results := []queryResult{}
+	for {
+		key, query, ok := iterator.Next()
+		if !ok {
+			break
+		}
+
+		var vel *Velocity
+		if query.Vel != nil {
+			vel = &Velocity{query.Vel.DX, query.Vel.DY}
+		}
+		results = append(results, queryResult{key, *query.Pos, vel})
+	}
+	return results
+}
+
+func TestQuery(t *testing.T) {
+	positions := sparseset.New[Position](4096, 1<<20)
+	velocities := sparseset.New[Velocity](4096, 1<<20)
+	frozen := sparseset.New[Frozen](4096, 1<<20)
+
+	for key := 0; key < 4; key++ {
+		*positions.Add(key) = Position{key, key}
+	}
+	*velocities.Add(1) = Velocity{1, 1}
+	*velocities.Add(2) = Velocity{2, 2}
+	*velocities.Add(5) = Velocity{5, 5}
+	frozen.Add(2)
+
+	query := sparseset.NewQuery[MoveQuery]()
+	sparseset.Bind(query, positions)
+	sparseset.Bind(query, velocities)
+	sparseset.Bind(query, frozen)
+
+	got := queryAll(query.Iterate())
+	want := []queryResult{
+		{0, Position{0, 0}, nil},
+		{1, Position{1, 1}, &Velocity{1, 1}},
+		{3, Position{3, 3}, nil},
+	}
+	if !slices.EqualFunc(got, want, func(r1, r2 queryResult) bool {
+		return r1.key == r2.key && r1.pos == r2.pos && (r1.vel == nil) == (r2.vel == nil) && (r1.vel == nil || *r1.vel == *r2.vel)
+	}) {
+		t.Errorf("results = %v; want %v", got, want)
+	}
+}
+
+func TestQuery_BindKeys(t *testing.T) {
+	positions := sparseset.New[Position](4096, 1<<20)
+	velocities := sparseset.New[Velocity](4096, 1<<20)
+	frozen := sparseset.NewKeySet(4096, 1<<20)
+
+	for key := 0; key < 4; key++ {
+		*positions.Add(key) = Position{key, key}
+	}
+	frozen.Add(1)
+	frozen.Add(3)
+
+	query := sparseset.NewQuery[MoveQuery]()
+	sparseset.Bind(query, positions)
+	sparseset.Bind(query, velocities)
+	sparseset.BindKeys[Frozen](query, frozen)
+
+	keys := []int{}
+	for iterator := query.Iterate(); ; {
+		key, _, ok := iterator.Next()
+		if !ok {
+			break
+		}
+		keys = append(keys, key)
+	}
+
+	if want := []int{0, 2}; !slices.Equal(keys, want) {
+		t.Errorf("keys = %v; want %v", keys, want)
+	}
+
+	// Keys added to the tags after binding are excluded too.
+	frozen.Add(0)
+	if got := queryAll(query.Iterate()); len(got) != 1 || got[0].key != 2 {
+		t.Errorf("results = %v; want key %d", got, 2)
+	}
+}
+
+func TestQuery_PointsToValues(t *testing.T) {
+	positions := sparseset.New[Position](4096, 1<<20)
+	velocities := sparseset.New[Velocity](4096, 1<<20)
+	frozen := sparseset.New[Frozen](4096, 1<<20)
+
+	for key := 0; key < 10; key++ {
+		*positions.Add(key) = Position{key, 0}
+		*velocities.Add(key) = Velocity{0, 1}
+	}
+
+	query := sparseset.NewQuery[MoveQuery]()
+	sparseset.Bind(query, positions)
+	sparseset.Bind(query, velocities)
+	sparseset.Bind(query, frozen)
+
+	for iterator := query.Iterate(); ; {
+		_, move, ok := iterator.Next()
+		if !ok {
+			break
+		}
+		move.Pos.Y += move.Vel.DY
+	}
+
+	for key := 0; key < 10; key++ {
+		if got, _ := positions.Get(key); *got != (Position{key, 1}) {
+			t.Errorf("Get(%d) = %v; want %v", key, *got, Position{key, 1})
+		}
+	}
+}
+
+func TestQuery_Reset(t *testing.T) {
+	positions := sparseset.New[Position](4096, 1<<20)
+	velocities := sparseset.New[Velocity](4096, 1<<20)
+	frozen := sparseset.New[Frozen](4096, 1<<20)
+	*positions.Add(0) = Position{}
+
+	query := sparseset.NewQuery[MoveQuery]()
+	sparseset.Bind(query, positions)
+	sparseset.Bind(query, velocities)
+	sparseset.Bind(query, frozen)
+
+	var iterator sparseset.QueryIterator[MoveQuery]
+	if _, _, ok := iterator.Next(); ok {
+		t.Errorf("Next() on zero value = _, _, %v; want _, _, %v", ok, false)
+	}
+
+	for i := 0; i < 2; i++ {
+		iterator.Reset(query)
+		if got := queryAll(&iterator); len(got) != 1 {
+			t.Errorf("results = %v; want 1 result", got)
+		}
+	}
+}
+
+func TestQuery_ZeroAllocs(t *testing.T) {
+	positions := sparseset.New[Position](4096, 1<<20)
+	velocities := sparseset.New[Velocity](4096, 1<<20)
+	frozen := sparseset.New[Frozen](4096, 1<<20)
+	for key := 0; key < 100; key++ {
+		*positions.Add(key) = Position{key, key}
+		*velocities.Add(key) = Velocity{1, 1}
+	}
+
+	query := sparseset.NewQuery[MoveQuery]()
+	sparseset.Bind(query, positions)
+	sparseset.Bind(query, velocities)
+	sparseset.Bind(query, frozen)
+
+	var iterator sparseset.QueryIterator[MoveQuery]
+	allocs := testing.AllocsPerRun(100, func() {
+		for iterator.Reset(query); ; {
+			_, move, ok := iterator.Next()
+			if !ok {
+				break
+			}
+			move.Pos.X += move.Vel.DX
+		}
+	})
+
+	if allocs != 0 {
+		t.Errorf("Next() allocs = %v; want %v", allocs, 0)
+	}
+}
+
+func TestQuery_Invalid(t *testing.T) {
+	type noRequired struct {
+		Pos *Position `sparseset:"optional"`
+		_   sparseset.Without[Frozen]
+	}
+
+	type notPointer struct {
+		Pos Position
+	}
+
+	type invalidTag struct {
+		Pos *Position `sparseset:"required"`
+	}
+
+	tests := []struct {
+		name string
+		f    func()
+	}{
+		{"NotStruct", func() { sparseset.NewQuery[int]() }},
+		{"NoRequired", func() { sparseset.NewQuery[noRequired]() }},
+		{"NotPointer", func() { sparseset.NewQuery[notPointer]() }},
+		{"InvalidTag", func() { sparseset.NewQuery[invalidTag]() }},
+		{"BindUnknownType", func() {
+			sparseset.Bind(sparseset.NewQuery[MoveQuery](), sparseset.New[int](4096, 1<<20))
+		}},
+		{"BindKeysPointerField", func() {
+			sparseset.BindKeys[Position](sparseset.NewQuery[MoveQuery](), sparseset.NewKeySet(4096, 1<<20))
+		}},
+		{"Unbound", func() {
+			query := sparseset.NewQuery[MoveQuery]()
+			sparseset.Bind(query, sparseset.New[Position](4096, 1<<20))
+			query.Iterate()
+		}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("%s did not panic", test.name)
+				}
+			}()
+			test.f()
+		})
+	}
+}
+
+func BenchmarkQuery(b *testing.B) {
+	positions := sparseset.New[Position](4096, 1<<20)
+	velocities := sparseset.New[Velocity](4096, 1<<20)
+	frozen := sparseset.New[Frozen](4096, 1<<20)
+	for key := 0; key < 10000; key++ {
+		*positions.Add(key) = Position{key, key}
+		*velocities.Add(key) = Velocity{1, 1}
+		if key%10 == 0 {
+			frozen.Add(key)
+		}
+	}
+
+	query := sparseset.NewQuery[MoveQuery]()
+	sparseset.Bind(query, positions)
+	sparseset.Bind(query, velocities)
+	sparseset.Bind(query, frozen)
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	for n := 0; n < b.N; n++ {
+		for iterator := query.Iterate(); ; {
+			_, move, ok := iterator.Next()
+			if !ok {
+				break
+			}
+			move.Pos.X += move.Vel.DX
+		}
+	}
+}
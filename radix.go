@@ -0,0 +1,168 @@
+package sparseset
+
+const (
+	// Number of bits of the page number consumed by each level of the radix
+	// directory.
+	radixBits   = 8
+	radixFanout = 1 << radixBits
+	radixMask   = radixFanout - 1
+	// Height of a directory that covers all 64-bit page numbers.
+	radixMaxHeight = 64 / radixBits
+)
+
+// radixNode is a node of a radixDirectory. Interior nodes have children and
+// leaf nodes have pages.
+type radixNode[Value any] struct {
+	children []*radixNode[Value]
+	pages    []page2[Value]
+	// Number of children (interior nodes) or pages with values (leaf nodes).
+	numEntries int
+}
+
+// radixDirectory is a radix tree of pages indexed by page number. Nodes are
+// only allocated along the paths of pages that hold values, and the height of
+// the tree only grows as needed to cover the largest page number, so that the
+// directory supports huge and sparse key spaces.
+type radixDirectory[Value any] struct {
+	root *radixNode[Value]
+	// Number of levels of the tree, including the leaves. This is 0 if the tree
+	// is empty.
+	height int
+	// Number of interior and leaf nodes.
+	numInterior int
+	numLeaves   int
+}
+
+// covers returns true if pageNum is within the range of the tree.
+func (d *radixDirectory[Value]) covers(pageNum uint64) bool {
+	return d.height >= radixMaxHeight || pageNum>>(radixBits*d.height) == 0
+}
+
+func (d *radixDirectory[Value]) newNode(leaf bool) *radixNode[Value] {
+	if leaf {
+		d.numLeaves++
+		return &radixNode[Value]{nil, make([]page2[Value], radixFanout), 0}
+	}
+
+	d.numInterior++
+	return &radixNode[Value]{make([]*radixNode[Value], radixFanout), nil, 0}
+}
+
+// get returns the page with the given number or nil if the page is not in the
+// directory.
+func (d *radixDirectory[Value]) get(pageNum uint64) *page2[Value] {
+	if d.root == nil || !d.covers(pageNum) {
+		return nil
+	}
+
+	node := d.root
+	for level := d.height - 1; level > 0; level-- {
+		node = node.children[(pageNum>>(radixBits*level))&radixMask]
+		if node == nil {
+			return nil
+		}
+	}
+
+	return &node.pages[pageNum&radixMask]
+}
+
+// getOrCreate returns the page with the given number, allocating the nodes
+// along its path. The caller must initialize the page if it has no values.
+func (d *radixDirectory[Value]) getOrCreate(pageNum uint64) *page2[Value] {
+	if d.root == nil {
+		// Create the root directly at the height that covers pageNum, so that no
+		// nodes are allocated along the path of page 0.
+		d.height = 1
+		for !d.covers(pageNum) {
+			d.height++
+		}
+		d.root = d.newNode(d.height == 1 /* leaf */)
+	}
+
+	for !d.covers(pageNum) {
+		root := d.newNode(false /* leaf */)
+		root.children[0] = d.root
+		root.numEntries = 1
+
+		d.root = root
+		d.height++
+	}
+
+	node := d.root
+	for level := d.height - 1; level > 0; level-- {
+		i := (pageNum >> (radixBits * level)) & radixMask
+
+		child := node.children[i]
+		if child == nil {
+			child = d.newNode(level == 1)
+			node.children[i] = child
+			node.numEntries++
+		}
+		node = child
+	}
+
+	page := &node.pages[pageNum&radixMask]
+	if page.values == nil {
+		node.numEntries++
+	}
+	return page
+}
+
+// prune must be called after the values of the page with the given number have
+// been released. It releases the nodes that become empty.
+func (d *radixDirectory[Value]) prune(pageNum uint64) {
+	var parents [radixMaxHeight]*radixNode[Value]
+	var indices [radixMaxHeight]uint64
+
+	node := d.root
+	for level := d.height - 1; level > 0; level-- {
+		i := (pageNum >> (radixBits * level)) & radixMask
+		parents[level], indices[level] = node, i
+		node = node.children[i]
+	}
+
+	node.numEntries--
+	if node.numEntries > 0 {
+		return
+	}
+	d.numLeaves--
+
+	for level := 1; level < d.height; level++ {
+		parent := parents[level]
+		parent.children[indices[level]] = nil
+		parent.numEntries--
+
+		if parent.numEntries > 0 {
+			return
+		}
+		d.numInterior--
+	}
+
+	// The whole tree is empty.
+	d.root = nil
+	d.height = 0
+}
+
+// forEach calls f with the number of every page with values.
+func (d *radixDirectory[Value]) forEach(f func(uint64, *page2[Value])) {
+	if d.root != nil {
+		d.forEachNode(d.root, d.height-1, 0, f)
+	}
+}
+
+func (d *radixDirectory[Value]) forEachNode(node *radixNode[Value], level int, prefix uint64, f func(uint64, *page2[Value])) {
+	if level == 0 {
+		for i := range node.pages {
+			if node.pages[i].values != nil {
+				f(prefix|uint64(i), &node.pages[i])
+			}
+		}
+		return
+	}
+
+	for i, child := range node.children {
+		if child != nil {
+			d.forEachNode(child, level-1, prefix|uint64(i)<<(radixBits*level), f)
+		}
+	}
+}
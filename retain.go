@@ -0,0 +1,101 @@
+package sparseset
+
+// RemoveIf removes the keys (and values) of the set for which remove returns
+// true, calling the DestroyValue option for each of them, and returns the
+// number of keys removed. This traverses the set once, filling the holes left
+// by the removed keys with the keys from the end of the set, therefore it does
+// not preserve the order of the set (see RemoveIfStable()).
+//
+// The remove function is called exactly once for each key and it must not
+// modify the set.
+func RemoveIf[Value any, K Key](set *SetOf[K, Value], remove func(K, *Value) bool) int {
+	length := len(set.dense)
+
+	for i, n := 0, length; i < n; {
+		if !remove(set.dense[i], &set.store[i]) {
+			i++
+			continue
+		}
+		set.removeAt(i)
+
+		// Find the last key that is not removed to fill the hole at i.
+		for n--; n > i; n-- {
+			if !remove(set.dense[n], &set.store[n]) {
+				break
+			}
+			set.removeAt(n)
+		}
+
+		if n > i {
+			set.move(n, i)
+			set.sortedByKey = false
+			i++
+		}
+	}
+
+	return set.truncate(length)
+}
+
+// RemoveIfStable is like RemoveIf but preserves the relative order of the keys
+// that are not removed. This moves more values than RemoveIf().
+func RemoveIfStable[Value any, K Key](set *SetOf[K, Value], remove func(K, *Value) bool) int {
+	length := len(set.dense)
+
+	n := 0
+	for i := 0; i < length; i++ {
+		if remove(set.dense[i], &set.store[i]) {
+			set.removeAt(i)
+			continue
+		}
+
+		if n != i {
+			set.move(i, n)
+		}
+		n++
+	}
+
+	return set.truncate(length)
+}
+
+// Retain removes the keys (and values) of the set for which keep returns false
+// and returns the number of keys removed (see RemoveIf()).
+func Retain[Value any, K Key](set *SetOf[K, Value], keep func(K, *Value) bool) int {
+	return RemoveIf(set, func(key K, value *Value) bool { return !keep(key, value) })
+}
+
+// RetainStable is like Retain but preserves the relative order of the keys that
+// are kept (see RemoveIfStable()).
+func RetainStable[Value any, K Key](set *SetOf[K, Value], keep func(K, *Value) bool) int {
+	return RemoveIfStable(set, func(key K, value *Value) bool { return !keep(key, value) })
+}
+
+// removeAt destroys the value at pos and removes its key from the index, but
+// leaves the dense array and the store untouched.
+func (s *SetOf[K, Value]) removeAt(pos int) {
+	s.index.Unset(s.dense[pos])
+	s.notifyRemoved(s.dense[pos])
+	s.destroyValue(&s.store[pos])
+}
+
+// move moves the key and value at position from to position to.
+func (s *SetOf[K, Value]) move(from, to int) {
+	s.dense[to] = s.dense[from]
+	s.store[to] = s.store[from]
+	s.index.Set(s.dense[to], to)
+}
+
+// truncate releases the positions after the length of the index, which must
+// have been moved or removed, and returns the number of positions released.
+func (s *SetOf[K, Value]) truncate(length int) int {
+	n := s.index.Length()
+
+	// Facilitate GC.
+	clear(s.store[n:length])
+
+	s.dense = s.dense[:n]
+	s.store = s.store[:n]
+
+	s.shrinkBelowThreshold()
+
+	return length - n
+}
@@ -0,0 +1,146 @@
+package sparseset_test
+
+import (
+	"testing"
+
+	"github.com/jabolopes/go-sparseset"
+	"golang.org/x/exp/slices"
+)
+
+// checkSet checks that the keys and values of set are consistent with want.
+func checkSet(t *testing.T, set *sparseset.Set[MyValue], want map[int]MyValue) {
+	t.Helper()
+
+	if got := set.Length(); got != len(want) {
+		t.Errorf("Length() = %d; want %d", got, len(want))
+	}
+
+	if got := len(set.Values()); got != len(want) {
+		t.Errorf("len(Values()) = %d; want %d", got, len(want))
+	}
+
+	for key, wantValue := range want {
+		if got, ok := set.Get(key); !ok || *got != wantValue {
+			t.Errorf("Get(%d) = %v, %v; want %v, %v", key, got, ok, wantValue, true)
+		}
+	}
+
+	for i, key := range set.Keys() {
+		if got := set.Values()[i]; got != want[key] {
+			t.Errorf("Values()[%d] = %v; want %v", i, got, want[key])
+		}
+	}
+}
+
+func TestRemoveIf(t *testing.T) {
+	for _, stable := range []bool{false, true} {
+		destroyed := []int{}
+		options := sparseset.Options[MyValue]{DestroyValue: func(value *MyValue) {
+			destroyed = append(destroyed, value.value)
+		}}
+		set := sparseset.NewWithOptions[MyValue](1<<10, 1<<20, options)
+
+		want := map[int]MyValue{}
+		wantDestroyed := []int{}
+		for i := 0; i < 100; i++ {
+			set.Add(i).value = i
+			if i%3 == 0 || i >= 90 {
+				wantDestroyed = append(wantDestroyed, i)
+			} else {
+				want[i] = MyValue{i}
+			}
+		}
+
+		called := 0
+		remove := func(key int, value *MyValue) bool {
+			called++
+			return key%3 == 0 || key >= 90
+		}
+
+		var removed int
+		if stable {
+			removed = sparseset.RemoveIfStable(set, remove)
+		} else {
+			removed = sparseset.RemoveIf(set, remove)
+		}
+
+		if removed != len(wantDestroyed) {
+			t.Errorf("RemoveIf() (stable=%v) = %d; want %d", stable, removed, len(wantDestroyed))
+		}
+
+		if called != 100 {
+			t.Errorf("RemoveIf() (stable=%v) called remove %d times; want %d", stable, called, 100)
+		}
+
+		slices.Sort(destroyed)
+		if !slices.Equal(destroyed, wantDestroyed) {
+			t.Errorf("RemoveIf() (stable=%v) destroyed %v; want %v", stable, destroyed, wantDestroyed)
+		}
+
+		checkSet(t, set, want)
+
+		for _, key := range wantDestroyed {
+			if set.Has(key) {
+				t.Errorf("Has(%d) = %v; want %v", key, true, false)
+			}
+		}
+	}
+}
+
+func TestRemoveIfStable_PreservesOrder(t *testing.T) {
+	set := sparseset.New[MyValue](1<<10, 1<<20)
+	for _, key := range []int{5, 3, 8, 1, 9, 2, 7} {
+		set.Add(key).value = key
+	}
+
+	sparseset.RemoveIfStable(set, func(key int, _ *MyValue) bool { return key%2 == 1 && key != 7 })
+
+	if got, want := set.Keys(), []int{8, 2, 7}; !slices.Equal(got, want) {
+		t.Errorf("Keys() = %v; want %v", got, want)
+	}
+}
+
+func TestRetain(t *testing.T) {
+	for _, stable := range []bool{false, true} {
+		set := sparseset.New[MyValue](1<<10, 1<<20)
+		for i := 0; i < 10; i++ {
+			set.Add(i).value = i
+		}
+
+		keep := func(key int, _ *MyValue) bool { return key < 4 }
+
+		var removed int
+		if stable {
+			removed = sparseset.RetainStable(set, keep)
+		} else {
+			removed = sparseset.Retain(set, keep)
+		}
+
+		if removed != 6 {
+			t.Errorf("Retain() (stable=%v) = %d; want %d", stable, removed, 6)
+		}
+
+		checkSet(t, set, map[int]MyValue{0: {0}, 1: {1}, 2: {2}, 3: {3}})
+	}
+}
+
+func TestRemoveIf_AllAndNone(t *testing.T) {
+	set := sparseset.New[MyValue](1<<10, 1<<20)
+	for i := 0; i < 10; i++ {
+		set.Add(i).value = i
+	}
+
+	if got := sparseset.RemoveIf(set, func(int, *MyValue) bool { return false }); got != 0 {
+		t.Errorf("RemoveIf() = %d; want %d", got, 0)
+	}
+
+	if got := sparseset.RemoveIf(set, func(int, *MyValue) bool { return true }); got != 10 {
+		t.Errorf("RemoveIf() = %d; want %d", got, 10)
+	}
+
+	checkSet(t, set, map[int]MyValue{})
+
+	// The set is still usable after all keys are removed.
+	set.Add(3).value = 3
+	checkSet(t, set, map[int]MyValue{3: {3}})
+}
@@ -1,113 +1,219 @@
 package sparseset
 
+import (
+	"math"
+)
+
 type Options[Value any] struct {
 	DestroyValue func(*Value)
+	// If positive, Remove() releases the excess capacity of the set when the
+	// length of the set falls below this fraction of the capacity of the store
+	// (e.g., 0.25). The set keeps twice its length as capacity, so that adding and
+	// removing keys around the threshold does not reallocate the set every time.
+	// Thresholds above maxShrinkThreshold (0.25) are clamped to it.
+	//
+	// Releasing the capacity moves the values, therefore with this option
+	// Remove() invalidates the pointers to the values of the set (see Remove()).
+	ShrinkThreshold float64
+	// If not nil, allocates the pages of the index instead of a per-set pool. The
+	// page size of the allocator is used instead of defaultPageSize.
+	PageAllocator PageAllocator[int]
+	// Initial width of the positions stored in the index. The index is promoted
+	// to a wider width when the set outgrows it. This is ignored if
+	// PageAllocator is set.
+	IndexWidth IndexWidth
+	// If positive, the pages of the index above this number are stored in a radix
+	// directory (see PagedArrayOptions.MaxLinearPages).
+	MaxLinearPages int
+	// If true, the set accepts all non-negative keys and nullKey is ignored. If
+	// MaxLinearPages is not positive, it defaults to growableMaxLinearPages
+	// (1024), so that large keys do not allocate a huge slice of pages.
+	Growable bool
 }
 
-// Set is a sparse set with a value store.
+// Set is a SetOf with int keys.
+type Set[Value any] = SetOf[int, Value]
+
+// SetOf is a sparse set with a value store and keys of type K.
 //
 // The Set can be accessed via read-only operations and iterators
 // concurrently, but it cannot be concurrently accessed by readers and
 // writers, and cannot be concurrently accessed by multiple writers.
 //
 // This is thread-compatible.
-type Set[Value any] struct {
-	// Sparse (paged) array. Stores positions (pos) by key.
-	index *PagedArray[int]
-	// Stores keys by position (pos) contiguously.
-	dense []int
+type SetOf[K Key, Value any] struct {
+	// Stores positions (pos) by key and keys by position.
+	sparse[K]
 	// Stores values by position (pos) contiguously.
 	store []Value
 	// Destroy (or uninitializes) values when they are removed from the store.
 	destroyValue func(*Value)
+	// Fraction of the capacity of the store below which the set is shrunk.
+	shrinkThreshold float64
 }
 
-func (s *Set[Value]) Length() int     { return s.index.Length() }
-func (s *Set[Value]) Values() []Value { return s.store }
+func (s *SetOf[K, Value]) Values() []Value { return s.store }
 
-func (s *Set[Value]) Add(key int) *Value {
-	if key < 0 || key >= s.index.NullValue() {
+// Add inserts key into the set and returns a pointer to its value. If the key
+// is already in the set, it returns a pointer to the existing value. Returns
+// nil if the key is out of range, or panics in debug builds (see TryAdd()).
+func (s *SetOf[K, Value]) Add(key K) *Value {
+	pos, added, ok := s.add(key)
+	if !ok {
+		if debug {
+			panic(keyError(ErrKeyOutOfRange, key))
+		}
 		return nil
 	}
 
-	pos := s.index.Get(key)
-	if pos != s.index.NullValue() {
-		return &s.store[pos]
+	if added {
+		var value Value
+		s.store = append(s.store, value)
 	}
 
-	pos = len(s.store)
-
-	s.dense = append(s.dense, key)
-
-	var value Value
-	s.store = append(s.store, value)
-
-	s.index.Set(key, pos)
 	return &s.store[pos]
 }
 
-func (s *Set[Value]) Remove(key int) {
-	if key < 0 || key >= s.index.NullValue() {
-		return
+// Remove deletes key from the set. Does nothing if the key is not in the set.
+// If the key is out of range, this does nothing, or panics in debug builds (see
+// TryRemove()).
+//
+// If the ShrinkThreshold option is set, this can move the values of the set,
+// which invalidates the pointers to the values returned by Add() and Get()
+// (see Options.ShrinkThreshold).
+func (s *SetOf[K, Value]) Remove(key K) {
+	if debug && !s.inRange(key) {
+		panic(keyError(ErrKeyOutOfRange, key))
 	}
 
-	pos := s.index.Get(key)
-	if pos == s.index.NullValue() {
+	// The value being removed may be in the middle of the store, so to remove it
+	// we need to swap it with the store's last element and then clear it,
+	// otherwise this will break iteration since elements in the store won't be
+	// contiguous anymore.
+	pos, last, ok := s.remove(key)
+	if !ok {
 		return
 	}
 
-	last := len(s.store) - 1
-	var defaultValue Value
-
-	if pos == last {
-		// The value being removed is the store's last element, so to remove it from
-		// the set, unset it from the index and clear its value.
+	// Destroy store value.
+	s.destroyValue(&s.store[pos])
 
-		// Remove from index.
-		s.index.Unset(key)
+	// Facilitate GC.
+	var defaultValue Value
+	s.store[pos], s.store[last] = s.store[last], defaultValue
 
-		// Destroy store value.
-		s.destroyValue(&s.store[pos])
+	// Remove element from the store.
+	s.store = s.store[:last]
 
-		// Facilitate GC.
-		s.store[pos] = defaultValue
+	s.shrinkBelowThreshold()
+}
 
-		// Remove element from the store.
-		s.dense = s.dense[:last]
-		s.store = s.store[:last]
+// maxShrinkThreshold is the largest ShrinkThreshold option. Since the set keeps
+// twice its length as capacity when it shrinks, a larger threshold would shrink
+// the set again after a few removals.
+const maxShrinkThreshold = 0.25
 
+// shrinkBelowThreshold releases the excess capacity of the set if the length of
+// the set is below the ShrinkThreshold option, keeping twice the length of the
+// set as capacity.
+func (s *SetOf[K, Value]) shrinkBelowThreshold() {
+	if float64(len(s.store)) >= s.shrinkThreshold*float64(cap(s.store)) {
 		return
 	}
 
-	// The value being removed is in the middle of the store, so to remove it we
-	// need to swap it so that it becomes the store's last element and then clear
-	// it, otherwise this will break iteration since elements in the store won't
-	// be contiguous anymore.
+	s.index.ShrinkToFit()
+	s.dense = shrinkTo(s.dense, 2*len(s.dense))
+	s.store = shrinkTo(s.store, 2*len(s.store))
+}
 
-	s.index.Unset(key)
-	s.index.Set(s.dense[last], pos)
+// TryAdd is like Add but returns ErrKeyOutOfRange if the key is out of range.
+func (s *SetOf[K, Value]) TryAdd(key K) (*Value, error) {
+	if !s.inRange(key) {
+		return nil, keyError(ErrKeyOutOfRange, key)
+	}
+	return s.Add(key), nil
+}
 
-	// Destroy store value.
-	s.destroyValue(&s.store[pos])
+// TryRemove is like Remove but returns ErrKeyOutOfRange if the key is out of
+// range and ErrKeyNotFound if the key is not in the set.
+func (s *SetOf[K, Value]) TryRemove(key K) error {
+	if !s.inRange(key) {
+		return keyError(ErrKeyOutOfRange, key)
+	}
 
-	s.store[pos], s.store[last] = s.store[last], defaultValue
-	s.dense[pos], s.dense[last] = s.dense[last], s.index.NullValue()
+	if !s.Has(key) {
+		return keyError(ErrKeyNotFound, key)
+	}
 
-	s.dense = s.dense[:last]
-	s.store = s.store[:last]
+	s.Remove(key)
+	return nil
 }
 
-func (s *Set[Value]) Get(key int) (*Value, bool) {
-	if key < 0 || key >= s.index.NullValue() {
+func (s *SetOf[K, Value]) Get(key K) (*Value, bool) {
+	pos, ok := s.position(key)
+	if !ok {
 		return nil, false
 	}
 
-	pos := s.index.Get(key)
-	if pos == s.index.NullValue() {
-		return nil, false
+	return &s.store[pos], true
+}
+
+// Clear removes all the keys (and values) of the set, calling the DestroyValue
+// option for each of them, and returns the pages of the index to the
+// allocator. This does not release the capacity of the set (see Reset()).
+func (s *SetOf[K, Value]) Clear() {
+	for i := range s.store {
+		s.destroyValue(&s.store[i])
 	}
 
-	return &s.store[pos], true
+	// Facilitate GC.
+	clear(s.store)
+
+	s.store = s.store[:0]
+	s.clear()
+}
+
+// Reset is like Clear but also releases the capacity of the set.
+func (s *SetOf[K, Value]) Reset() {
+	s.Clear()
+	s.ShrinkToFit()
+}
+
+// KeyAt returns the key at position pos, which must be in the range [0,
+// Length()). The positions of the keys change when keys are removed or the set
+// is sorted.
+func (s *SetOf[K, Value]) KeyAt(pos int) K { return s.dense[pos] }
+
+// ValueAt returns a pointer to the value at position pos, which must be in the
+// range [0, Length()) (see KeyAt()).
+func (s *SetOf[K, Value]) ValueAt(pos int) *Value { return &s.store[pos] }
+
+// PositionOf returns the position of key (see KeyAt()).
+func (s *SetOf[K, Value]) PositionOf(key K) (int, bool) { return s.position(key) }
+
+// IndexWidth returns the current width of the positions stored in the index.
+func (s *SetOf[K, Value]) IndexWidth() IndexWidth { return s.index.Width() }
+
+// Compact reclaims the empty pages at the end of the index. This does not
+// release the capacity of the set (see ShrinkToFit()).
+func (s *SetOf[K, Value]) Compact() { s.compact() }
+
+// ShrinkToFit reclaims the empty pages at the end of the index and releases the
+// excess capacity of the set. This invalidates the pointers to the values of
+// the set.
+func (s *SetOf[K, Value]) ShrinkToFit() {
+	s.shrinkToFit()
+	s.store = shrink(s.store)
+}
+
+// growableMaxLinearPages is the default MaxLinearPages option of growable sets.
+const growableMaxLinearPages = 1024
+
+// NewGrowable returns a set that accepts all non-negative keys. The pages of the
+// index above the first growableMaxLinearPages are stored in a radix directory
+// (see Options.Growable).
+func NewGrowable[Value any](defaultPageSize int) *Set[Value] {
+	return NewSetOfWithOptions[int, Value](defaultPageSize, 0, Options[Value]{Growable: true})
 }
 
 func New[Value any](defaultPageSize, nullKey int) *Set[Value] {
@@ -115,14 +221,37 @@ func New[Value any](defaultPageSize, nullKey int) *Set[Value] {
 }
 
 func NewWithOptions[Value any](defaultPageSize, nullKey int, options Options[Value]) *Set[Value] {
+	return NewSetOfWithOptions[int, Value](defaultPageSize, nullKey, options)
+}
+
+// NewSetOf returns a set whose keys are of type K and less than nullKey.
+//
+// To accept all keys, use NewGrowable() or the Growable option.
+func NewSetOf[K Key, Value any](defaultPageSize int, nullKey K) *SetOf[K, Value] {
+	return NewSetOfWithOptions[K, Value](defaultPageSize, nullKey, Options[Value]{})
+}
+
+func NewSetOfWithOptions[K Key, Value any](defaultPageSize int, nullKey K, options Options[Value]) *SetOf[K, Value] {
 	if options.DestroyValue == nil {
 		options.DestroyValue = func(*Value) {}
 	}
 
-	return &Set[Value]{
-		NewPagedArray(defaultPageSize, nullKey),
-		[]int{},
+	nullPos := nullPosition(nullKey)
+	if options.Growable {
+		nullKey = maxKey[K]()
+		nullPos = math.MaxInt
+		if options.MaxLinearPages <= 0 {
+			options.MaxLinearPages = growableMaxLinearPages
+		}
+	}
+
+	keys := newSparse(newPositionIndex[K](defaultPageSize, nullPos, options.IndexWidth, PagedArrayOptions[int]{options.PageAllocator, options.MaxLinearPages}), nullKey)
+	keys.growable = options.Growable
+
+	return &SetOf[K, Value]{
+		keys,
 		[]Value{},
 		options.DestroyValue,
+		min(options.ShrinkThreshold, maxShrinkThreshold),
 	}
 }
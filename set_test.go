@@ -2,7 +2,9 @@ package sparseset_test
 
 import (
 	"cmp"
+	"errors"
 	"fmt"
+	"math"
 	"reflect"
 	"testing"
 	"testing/quick"
@@ -115,11 +117,18 @@ func TestAdd_Quick(t *testing.T) {
 
 	add := func(key int) bool {
 		length := set.Length()
+		valid := key >= 0 && key < nullValue
 
-		value := set.Add(key)
+		var value *MyValue
+		if valid || !debugBuild {
+			value = set.Add(key)
+		} else {
+			// Invalid keys panic in debug builds.
+			value, _ = set.TryAdd(key)
+		}
 		got, ok := set.Get(key)
 
-		if key >= 0 && key < nullValue {
+		if valid {
 			return value != nil && got == value && ok && set.Length() == length+1
 		}
 
@@ -136,7 +145,7 @@ func TestRemove(t *testing.T) {
 	const nullValue = 1 << 20
 
 	called := false
-	options := sparseset.Options[MyValue]{func(value *MyValue) {
+	options := sparseset.Options[MyValue]{DestroyValue: func(value *MyValue) {
 		called = true
 	}}
 	set := sparseset.NewWithOptions[MyValue](pageSize, nullValue, options)
@@ -166,12 +175,17 @@ func TestRemove_Quick(t *testing.T) {
 	const nullValue = 1 << 20
 
 	called := false
-	options := sparseset.Options[MyValue]{func(value *MyValue) {
+	options := sparseset.Options[MyValue]{DestroyValue: func(value *MyValue) {
 		called = true
 	}}
 	set := sparseset.NewWithOptions[MyValue](pageSize, nullValue, options)
 
 	remove := func(key int) bool {
+		if debugBuild && (key < 0 || key >= nullValue) {
+			// Invalid keys panic in debug builds (see TestDebug_Panics).
+			return true
+		}
+
 		called = false
 		value := set.Add(key)
 
@@ -264,3 +278,553 @@ func TestAddRemove_Sequence(t *testing.T) {
 		}
 	}
 }
+
+func TestShrinkToFit(t *testing.T) {
+	const n = 1000
+
+	const pageSize = 1 << 4
+	const nullValue = 1 << 20
+	set := sparseset.New[MyValue](pageSize, nullValue)
+
+	for i := 0; i < n; i++ {
+		set.Add(i).value = i
+	}
+
+	for i := 10; i < n; i++ {
+		set.Remove(i)
+	}
+
+	set.Compact()
+
+	if got := cap(set.Values()); got < n {
+		t.Errorf("cap(Values()) = %d; want >= %d", got, n)
+	}
+
+	set.ShrinkToFit()
+
+	if got := cap(set.Values()); got != 10 {
+		t.Errorf("cap(Values()) = %d; want %d", got, 10)
+	}
+
+	for i := 0; i < n; i++ {
+		got, ok := set.Get(i)
+		if i < 10 && (!ok || got.value != i) {
+			t.Errorf("Get(%d) = %v, %v; want %v, %v", i, got, ok, MyValue{i}, true)
+		}
+		if i >= 10 && ok {
+			t.Errorf("Get(%d) = %v, %v; want %v, %v", i, got, ok, nil, false)
+		}
+	}
+
+	// The set is still usable after shrinking.
+	set.Add(n).value = n
+	if got, ok := set.Get(n); !ok || got.value != n {
+		t.Errorf("Get(%d) = %v, %v; want %v, %v", n, got, ok, MyValue{n}, true)
+	}
+}
+
+func TestShrinkThreshold(t *testing.T) {
+	const n = 1000
+
+	const pageSize = 1 << 10
+	const nullValue = 1 << 20
+	options := sparseset.Options[MyValue]{ShrinkThreshold: 0.25}
+	set := sparseset.NewWithOptions[MyValue](pageSize, nullValue, options)
+
+	for i := 0; i < n; i++ {
+		set.Add(i).value = i
+	}
+
+	capacity := cap(set.Values())
+	for i := n - 1; i >= 0; i-- {
+		set.Remove(i)
+
+		if got, limit := cap(set.Values()), float64(capacity)*0.25; float64(i) < limit && got != 2*i {
+			t.Fatalf("cap(Values()) = %d after %d removals; want %d", got, n-i, 2*i)
+		}
+
+		capacity = cap(set.Values())
+	}
+}
+
+func TestShrinkThreshold_Clamped(t *testing.T) {
+	const n = 1000
+
+	options := sparseset.Options[MyValue]{ShrinkThreshold: 0.9}
+	set := sparseset.NewWithOptions[MyValue](1<<10, 1<<20, options)
+
+	for i := 0; i < n; i++ {
+		set.Add(i).value = i
+	}
+
+	// Adding and removing a key around the threshold does not reallocate the
+	// set.
+	set.ShrinkToFit()
+	set.Add(n)
+	values := set.Values()
+	for i := 0; i < 10; i++ {
+		set.Remove(n)
+		set.Add(n)
+	}
+
+	if got, want := &set.Values()[0], &values[0]; got != want {
+		t.Errorf("&Values()[0] = %p after Add() and Remove(); want %p", got, want)
+	}
+}
+
+func TestClear(t *testing.T) {
+	const n = 100
+
+	allocator := sparseset.NewFreeListAllocator[int](1 << 4)
+	destroyed := 0
+	options := sparseset.Options[MyValue]{
+		DestroyValue:  func(*MyValue) { destroyed++ },
+		PageAllocator: allocator,
+	}
+	set := sparseset.NewWithOptions[MyValue](0, 1<<20, options)
+
+	for i := 0; i < n; i++ {
+		set.Add(i).value = i
+	}
+
+	capacity := cap(set.Values())
+	set.Clear()
+
+	if destroyed != n {
+		t.Errorf("Clear() destroyed %d values; want %d", destroyed, n)
+	}
+
+	if got := set.Length(); got != 0 {
+		t.Errorf("Length() = %d; want %d", got, 0)
+	}
+
+	if got := len(set.Values()); got != 0 {
+		t.Errorf("len(Values()) = %d; want %d", got, 0)
+	}
+
+	if got := cap(set.Values()); got != capacity {
+		t.Errorf("cap(Values()) = %d; want %d", got, capacity)
+	}
+
+	if got, want := allocator.Length(), (n+15)/16; got != want {
+		t.Errorf("allocator.Length() = %d; want %d", got, want)
+	}
+
+	for i := 0; i < n; i++ {
+		if got, ok := set.Get(i); ok {
+			t.Errorf("Get(%d) = %v, %v; want %v, %v", i, got, ok, nil, false)
+		}
+	}
+
+	// The set is still usable after clearing.
+	set.Add(n).value = n
+	if got, ok := set.Get(n); !ok || got.value != n {
+		t.Errorf("Get(%d) = %v, %v; want %v, %v", n, got, ok, MyValue{n}, true)
+	}
+}
+
+func TestReset(t *testing.T) {
+	const n = 100
+
+	destroyed := 0
+	options := sparseset.Options[MyValue]{DestroyValue: func(*MyValue) { destroyed++ }}
+	set := sparseset.NewWithOptions[MyValue](1<<4, 1<<20, options)
+
+	for i := 0; i < n; i++ {
+		set.Add(i).value = i
+	}
+
+	set.Reset()
+
+	if destroyed != n {
+		t.Errorf("Reset() destroyed %d values; want %d", destroyed, n)
+	}
+
+	if got := cap(set.Values()); got != 0 {
+		t.Errorf("cap(Values()) = %d; want %d", got, 0)
+	}
+
+	if got := set.Stats().Index.Pages; got != 0 {
+		t.Errorf("Stats().Index.Pages = %d; want %d", got, 0)
+	}
+
+	set.Add(3).value = 3
+	if got, ok := set.Get(3); !ok || got.value != 3 {
+		t.Errorf("Get(%d) = %v, %v; want %v, %v", 3, got, ok, MyValue{3}, true)
+	}
+}
+
+func TestKeyAtValueAtPositionOf(t *testing.T) {
+	set := sparseset.New[MyValue](1<<10, 1<<20)
+	for _, key := range []int{7, 3, 5} {
+		set.Add(key).value = key * 10
+	}
+	set.Remove(7)
+
+	for pos, want := range []int{5, 3} {
+		if got := set.KeyAt(pos); got != want {
+			t.Errorf("KeyAt(%d) = %d; want %d", pos, got, want)
+		}
+
+		if got := set.ValueAt(pos); got.value != want*10 {
+			t.Errorf("ValueAt(%d) = %v; want %v", pos, got, MyValue{want * 10})
+		}
+
+		if got, ok := set.PositionOf(want); got != pos || !ok {
+			t.Errorf("PositionOf(%d) = %d, %v; want %d, %v", want, got, ok, pos, true)
+		}
+	}
+
+	for _, key := range []int{7, -1, 1 << 20} {
+		if got, ok := set.PositionOf(key); ok {
+			t.Errorf("PositionOf(%d) = %d, %v; want _, %v", key, got, ok, false)
+		}
+	}
+}
+
+type entityID uint32
+
+func TestSetOf(t *testing.T) {
+	const pageSize = 1 << 10
+	const nullKey = entityID(1 << 20)
+	set := sparseset.NewSetOf[entityID, MyValue](pageSize, nullKey)
+
+	for i := entityID(0); i < 100; i++ {
+		set.Add(i).value = int(i)
+	}
+	set.Remove(50)
+
+	if got, err := set.TryAdd(nullKey); got != nil || !errors.Is(err, sparseset.ErrKeyOutOfRange) {
+		t.Errorf("TryAdd(%d) = %v, %v; want %v, %v", nullKey, got, err, nil, sparseset.ErrKeyOutOfRange)
+	}
+
+	if got := set.Length(); got != 99 {
+		t.Errorf("Length() = %d; want %d", got, 99)
+	}
+
+	for i := entityID(0); i < 100; i++ {
+		got, ok := set.Get(i)
+		if i == 50 {
+			if ok {
+				t.Errorf("Get(%d) = %v, %v; want %v, %v", i, got, ok, nil, false)
+			}
+			continue
+		}
+
+		if !ok || got.value != int(i) {
+			t.Errorf("Get(%d) = %v, %v; want %v, %v", i, got, ok, MyValue{int(i)}, true)
+		}
+	}
+
+	if got, want := set.Stats().DenseBytes, 4*cap(set.Keys()); got != want {
+		t.Errorf("Stats().DenseBytes = %d; want %d", got, want)
+	}
+}
+
+func TestSetOf_SmallKeyType(t *testing.T) {
+	// The page size is larger than the range of the key type.
+	set := sparseset.NewSetOf[uint8, int](1<<10, 255)
+
+	for i := 0; i < 255; i++ {
+		*set.Add(uint8(i)) = i
+	}
+
+	if got, err := set.TryAdd(255); got != nil || !errors.Is(err, sparseset.ErrKeyOutOfRange) {
+		t.Errorf("TryAdd(%d) = %v, %v; want %v, %v", 255, got, err, nil, sparseset.ErrKeyOutOfRange)
+	}
+
+	for i := 0; i < 255; i++ {
+		if got, ok := set.Get(uint8(i)); !ok || *got != i {
+			t.Errorf("Get(%d) = %v, %v; want %v, %v", i, got, ok, i, true)
+		}
+	}
+}
+
+func TestSetOf_IterateJoinLookup(t *testing.T) {
+	set1 := sparseset.NewSetOf[entityID, string](1<<10, 1<<20)
+	set2 := sparseset.NewSetOf[entityID, int](1<<10, 1<<20)
+
+	for i := entityID(0); i < 10; i++ {
+		*set1.Add(i) = fmt.Sprint(i)
+		if i%2 == 0 {
+			*set2.Add(i) = int(i)
+		}
+	}
+
+	keys := []entityID{}
+	for iterator := sparseset.Iterate(set1); ; {
+		key, _, ok := iterator.Next()
+		if !ok {
+			break
+		}
+		keys = append(keys, key)
+	}
+
+	if want := []entityID{0, 1, 2, 3, 4, 5, 6, 7, 8, 9}; !slices.Equal(keys, want) {
+		t.Errorf("Iterate() keys = %v; want %v", keys, want)
+	}
+
+	keys = []entityID{}
+	for iterator := sparseset.Join(set1, set2); ; {
+		key, a, b, ok := iterator.Next()
+		if !ok {
+			break
+		}
+
+		if *a != fmt.Sprint(key) || *b != int(key) {
+			t.Errorf("Join() = %d, %v, %v; want %d, %v, %v", key, *a, *b, key, fmt.Sprint(key), key)
+		}
+		keys = append(keys, key)
+	}
+
+	if want := []entityID{0, 2, 4, 6, 8}; !slices.Equal(keys, want) {
+		t.Errorf("Join() keys = %v; want %v", keys, want)
+	}
+
+	if _, _, ok := sparseset.Lookup(entityID(4), set1, set2); !ok {
+		t.Errorf("Lookup(4) = _, _, %v; want _, _, %v", ok, true)
+	}
+
+	if _, _, ok := sparseset.Lookup(entityID(5), set1, set2); ok {
+		t.Errorf("Lookup(5) = _, _, %v; want _, _, %v", ok, false)
+	}
+}
+
+func TestIndexWidth(t *testing.T) {
+	const n = 1 << 17
+
+	const pageSize = 1 << 10
+	const nullValue = 1 << 20
+	options := sparseset.Options[MyValue]{IndexWidth: sparseset.IndexWidth16}
+	set := sparseset.NewWithOptions[MyValue](pageSize, nullValue, options)
+
+	for i := 0; i < n; i++ {
+		set.Add(i).value = i
+
+		want := sparseset.IndexWidth16
+		if i >= 1<<16-1 {
+			want = sparseset.IndexWidth32
+		}
+
+		if got := set.IndexWidth(); got != want {
+			t.Fatalf("IndexWidth() = %v after Add(%d); want %v", got, i, want)
+		}
+	}
+
+	for i := 0; i < n; i += 2 {
+		set.Remove(i)
+	}
+
+	if got := set.Length(); got != n/2 {
+		t.Errorf("Length() = %d; want %d", got, n/2)
+	}
+
+	for i := 0; i < n; i++ {
+		got, ok := set.Get(i)
+		if i%2 == 0 && ok {
+			t.Errorf("Get(%d) = %v, %v; want %v, %v", i, got, ok, nil, false)
+		}
+		if i%2 == 1 && (!ok || got.value != i) {
+			t.Errorf("Get(%d) = %v, %v; want %v, %v", i, got, ok, MyValue{i}, true)
+		}
+	}
+}
+
+func TestIndexWidth_Stats(t *testing.T) {
+	const pageSize = 1 << 10
+	const nullValue = 1 << 20
+
+	bytes := map[sparseset.IndexWidth]int{}
+	for _, width := range []sparseset.IndexWidth{sparseset.IndexWidth16, sparseset.IndexWidth32, sparseset.IndexWidthInt} {
+		options := sparseset.Options[MyValue]{IndexWidth: width}
+		set := sparseset.NewWithOptions[MyValue](pageSize, nullValue, options)
+		for i := 0; i < 1000; i++ {
+			set.Add(i)
+		}
+
+		if got := set.IndexWidth(); got != width {
+			t.Errorf("IndexWidth() = %v; want %v", got, width)
+		}
+
+		bytes[width] = set.Stats().Index.Bytes
+	}
+
+	if !(bytes[sparseset.IndexWidth16] < bytes[sparseset.IndexWidth32] && bytes[sparseset.IndexWidth32] < bytes[sparseset.IndexWidthInt]) {
+		t.Errorf("Stats().Index.Bytes = %v; want increasing with the width", bytes)
+	}
+}
+
+func TestMaxLinearPages(t *testing.T) {
+	const pageSize = 1 << 10
+	options := sparseset.Options[MyValue]{MaxLinearPages: 16}
+	set := sparseset.NewSetOfWithOptions[uint64, MyValue](pageSize, math.MaxUint64, options)
+
+	keys := []uint64{0, 1 << 20, 1 << 40, 0xdeadbeefcafebabe, math.MaxUint64 - 1}
+	for i, key := range keys {
+		set.Add(key).value = i
+	}
+
+	for i, key := range keys {
+		if got, ok := set.Get(key); !ok || got.value != i {
+			t.Errorf("Get(%d) = %v, %v; want %v, %v", key, got, ok, MyValue{i}, true)
+		}
+	}
+
+	set.Remove(1 << 40)
+
+	if got, ok := set.Get(1 << 40); ok {
+		t.Errorf("Get(%d) = %v, %v; want %v, %v", uint64(1<<40), got, ok, nil, false)
+	}
+
+	if got := set.Stats().Index.Pages; got > 1+3 {
+		t.Errorf("Stats().Index.Pages = %d; want <= %d", got, 1+3)
+	}
+}
+
+func TestTryAdd(t *testing.T) {
+	const pageSize = 1 << 10
+	const nullValue = 1 << 20
+	set := sparseset.New[MyValue](pageSize, nullValue)
+
+	value, err := set.TryAdd(10)
+	if value == nil || err != nil {
+		t.Errorf("TryAdd(10) = %v, %v; want non-%v, %v", value, err, nil, nil)
+	}
+
+	for _, key := range []int{-1, nullValue} {
+		if got, err := set.TryAdd(key); got != nil || !errors.Is(err, sparseset.ErrKeyOutOfRange) {
+			t.Errorf("TryAdd(%d) = %v, %v; want %v, %v", key, got, err, nil, sparseset.ErrKeyOutOfRange)
+		}
+	}
+
+	if got := set.Length(); got != 1 {
+		t.Errorf("Length() = %d; want %d", got, 1)
+	}
+}
+
+func TestTryRemove(t *testing.T) {
+	const pageSize = 1 << 10
+	const nullValue = 1 << 20
+	set := sparseset.New[MyValue](pageSize, nullValue)
+	set.Add(10)
+
+	if err := set.TryRemove(10); err != nil {
+		t.Errorf("TryRemove(10) = %v; want %v", err, nil)
+	}
+
+	if err := set.TryRemove(10); !errors.Is(err, sparseset.ErrKeyNotFound) {
+		t.Errorf("TryRemove(10) = %v; want %v", err, sparseset.ErrKeyNotFound)
+	}
+
+	for _, key := range []int{-1, nullValue} {
+		if err := set.TryRemove(key); !errors.Is(err, sparseset.ErrKeyOutOfRange) {
+			t.Errorf("TryRemove(%d) = %v; want %v", key, err, sparseset.ErrKeyOutOfRange)
+		}
+	}
+}
+
+func TestNewGrowable(t *testing.T) {
+	set := sparseset.NewGrowable[MyValue](1 << 10)
+
+	keys := []int{0, 1 << 20, 1 << 22}
+	for i, key := range keys {
+		set.Add(key).value = i
+	}
+
+	for i, key := range keys {
+		if got, ok := set.Get(key); !ok || got.value != i {
+			t.Errorf("Get(%d) = %v, %v; want %v, %v", key, got, ok, MyValue{i}, true)
+		}
+	}
+
+	if got, err := set.TryAdd(-1); got != nil || !errors.Is(err, sparseset.ErrKeyOutOfRange) {
+		t.Errorf("TryAdd(-1) = %v, %v; want %v, %v", got, err, nil, sparseset.ErrKeyOutOfRange)
+	}
+}
+
+func TestNewGrowable_LargeKeys(t *testing.T) {
+	set := sparseset.NewGrowable[MyValue](64)
+
+	keys := []int{1<<40 - 1, 1 << 40, 1<<40 + 64, math.MaxInt - 1}
+	for i, key := range keys {
+		set.Add(key).value = i
+	}
+
+	for i, key := range keys {
+		if got, ok := set.Get(key); !ok || got.value != i {
+			t.Errorf("Get(%d) = %v, %v; want %v, %v", key, got, ok, MyValue{i}, true)
+		}
+	}
+
+	if got, want := set.Length(), len(keys); got != want {
+		t.Errorf("Length() = %d; want %d", got, want)
+	}
+
+	for _, key := range keys {
+		set.Remove(key)
+	}
+
+	if got := set.Length(); got != 0 {
+		t.Errorf("Length() = %d; want %d", got, 0)
+	}
+}
+
+func TestGrowable_AllKeys(t *testing.T) {
+	options := sparseset.Options[MyValue]{Growable: true, MaxLinearPages: 1}
+	set := sparseset.NewSetOfWithOptions[uint8, MyValue](16, 0, options)
+
+	for i := 0; i <= math.MaxUint8; i++ {
+		set.Add(uint8(i)).value = i
+	}
+
+	if got := set.Length(); got != math.MaxUint8+1 {
+		t.Errorf("Length() = %d; want %d", got, math.MaxUint8+1)
+	}
+
+	if err := set.TryRemove(math.MaxUint8); err != nil {
+		t.Errorf("TryRemove(%d) = %v; want %v", math.MaxUint8, err, nil)
+	}
+
+	hashed := sparseset.NewSetOfWithOptions[int64, MyValue](1<<10, 0, sparseset.Options[MyValue]{Growable: true, MaxLinearPages: 1})
+	if got := hashed.Add(math.MaxInt64); got == nil {
+		t.Errorf("Add(%d) = %v; want non-%v", int64(math.MaxInt64), got, nil)
+	}
+}
+
+// BenchmarkGet looks up all the keys of a set with the default index width,
+// which takes the fast path of the index.
+func BenchmarkGet(b *testing.B) {
+	const n = 100000
+
+	set := newUnsortedSet(n, 1)
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	sum := 0
+	for i := 0; i < b.N; i++ {
+		for key := 0; key < n; key++ {
+			value, _ := set.Get(key)
+			sum += *value
+		}
+	}
+}
+
+func BenchmarkGet_IndexWidth16(b *testing.B) {
+	const n = 50000
+
+	options := sparseset.Options[int]{IndexWidth: sparseset.IndexWidth16}
+	set := sparseset.NewWithOptions[int](4096, 1<<20, options)
+	for key := n - 1; key >= 0; key-- {
+		*set.Add(key) = key
+	}
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	sum := 0
+	for i := 0; i < b.N; i++ {
+		for key := 0; key < n; key++ {
+			value, _ := set.Get(key)
+			sum += *value
+		}
+	}
+}
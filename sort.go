@@ -1,6 +1,8 @@
 package sparseset
 
 import (
+	"cmp"
+
 	"golang.org/x/exp/slices"
 )
 
@@ -8,8 +10,25 @@ import (
 // receives the 'left-hand-side' ID and Value and the 'right-hand-side' ID and
 // Value. The 'compare' function should call methods on the Set (e.g.,
 // Set.Get()) since SortStableFunc modifies the Set.
-func SortStableFunc[T any](set *Set[T], compare func(int, *T, int, *T) int) {
-	slices.SortStableFunc(set.dense, func(i, j int) int {
+func SortStableFunc[T any, K Key](set *SetOf[K, T], compare func(K, *T, K, *T) int) {
+	sortFunc(set, compare)
+	set.sortedByKey = slices.IsSorted(set.dense)
+}
+
+// SortByKey sorts the Set by key in ascending order. The joins of sets that are
+// sorted by key and have comparable lengths use a merge join, which is faster
+// than looking up the keys (see Set.SortedByKey()).
+func SortByKey[T any, K Key](set *SetOf[K, T]) {
+	if set.sortedByKey {
+		return
+	}
+
+	sortFunc(set, func(i K, _ *T, j K, _ *T) int { return cmp.Compare(i, j) })
+	set.sortedByKey = true
+}
+
+func sortFunc[T any, K Key](set *SetOf[K, T], compare func(K, *T, K, *T) int) {
+	slices.SortStableFunc(set.dense, func(i, j K) int {
 		iPos := set.index.Get(i)
 		jPos := set.index.Get(j)
 		return compare(i, &set.store[iPos], j, &set.store[jPos])
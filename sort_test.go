@@ -59,3 +59,60 @@ func TestSort_EmptySet(t *testing.T) {
 		t.Errorf("results = %v; want %v", got, want)
 	}
 }
+
+func TestSortByKey(t *testing.T) {
+	set := sparseset.New[int](4096, 1<<20)
+	for _, key := range []int{5, 1, 9, 3} {
+		*set.Add(key) = key * 10
+	}
+
+	if set.SortedByKey() {
+		t.Errorf("SortedByKey() = %v; want %v", true, false)
+	}
+
+	sparseset.SortByKey(set)
+
+	if !set.SortedByKey() {
+		t.Errorf("SortedByKey() = %v; want %v", false, true)
+	}
+
+	if got, want := set.Keys(), []int{1, 3, 5, 9}; !slices.Equal(got, want) {
+		t.Errorf("Keys() = %v; want %v", got, want)
+	}
+
+	if got, want := set.Values(), []int{10, 30, 50, 90}; !slices.Equal(got, want) {
+		t.Errorf("Values() = %v; want %v", got, want)
+	}
+}
+
+func TestSortedByKey(t *testing.T) {
+	set := sparseset.New[int](4096, 1<<20)
+
+	steps := []struct {
+		name string
+		f    func()
+		want bool
+	}{
+		{"empty", func() {}, true},
+		{"Add ascending", func() { set.Add(1); set.Add(2); set.Add(5); set.Add(7) }, true},
+		{"Add existing", func() { set.Add(1) }, true},
+		{"Remove last", func() { set.Remove(7) }, true},
+		{"Remove first", func() { set.Remove(1) }, false},
+		{"SortByKey", func() { sparseset.SortByKey(set) }, true},
+		{"Add descending", func() { set.Add(4); set.Add(3) }, false},
+		{"SortByKey", func() { sparseset.SortByKey(set) }, true},
+		{"Remove middle", func() { set.Remove(3) }, false},
+		{"SortStableFunc", func() {
+			sparseset.SortStableFunc(set, func(k1 int, _ *int, k2 int, _ *int) int { return cmp.Compare(k1, k2) })
+		}, true},
+		{"AddRange", func() { set.AddRange(0, 2, nil) }, false},
+		{"Clear", func() { set.Clear() }, true},
+	}
+
+	for _, step := range steps {
+		step.f()
+		if got := set.SortedByKey(); got != step.want {
+			t.Errorf("SortedByKey() after %s = %v; want %v", step.name, got, step.want)
+		}
+	}
+}
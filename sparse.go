@@ -0,0 +1,215 @@
+package sparseset
+
+import (
+	"math"
+	"unsafe"
+
+	"golang.org/x/exp/slices"
+)
+
+// sparse is the key bookkeeping of a sparse set, i.e., the index from keys to
+// positions and the dense array of keys by position. It is shared by the
+// different set types, which store their values by position alongside it.
+type sparse[K Key] struct {
+	// Sparse (paged) array. Stores positions (pos) by key.
+	index *positionIndex[K]
+	// Stores keys by position (pos) contiguously.
+	dense []K
+	// Keys must be less than nullKey, unless the set is growable.
+	nullKey K
+	// If true, all non-negative keys are valid.
+	growable bool
+	// If true, the keys of dense are in ascending order (see SortByKey()).
+	sortedByKey bool
+	// Notified when keys are added to or removed from the set (e.g., View).
+	observers []keyObserver[K]
+}
+
+// keyObserver is notified when keys are added to or removed from a set, after
+// the index of the set is updated.
+type keyObserver[K Key] interface {
+	keyAdded(key K)
+	keyRemoved(key K)
+	// All keys were removed.
+	keysCleared()
+}
+
+func (s *sparse[K]) observe(observer keyObserver[K]) {
+	s.observers = append(s.observers, observer)
+}
+
+func (s *sparse[K]) unobserve(observer keyObserver[K]) {
+	if i := slices.Index(s.observers, observer); i >= 0 {
+		s.observers = slices.Delete(s.observers, i, i+1)
+	}
+}
+
+func (s *sparse[K]) notifyAdded(key K) {
+	for _, observer := range s.observers {
+		observer.keyAdded(key)
+	}
+}
+
+func (s *sparse[K]) notifyRemoved(key K) {
+	for _, observer := range s.observers {
+		observer.keyRemoved(key)
+	}
+}
+
+// inRange returns true if the key is valid for this set.
+func (s *sparse[K]) inRange(key K) bool {
+	return key >= 0 && (key < s.nullKey || s.growable)
+}
+
+func (s *sparse[K]) Length() int { return s.index.Length() }
+
+// Has returns true if the key is in the set.
+func (s *sparse[K]) Has(key K) bool {
+	_, ok := s.position(key)
+	return ok
+}
+
+// Keys returns the keys of the set by position.
+func (s *sparse[K]) Keys() []K { return s.dense }
+
+// SortedByKey returns true if the keys of the set are in ascending order by
+// position, e.g., after SortByKey() or if the keys were added in ascending
+// order. Adding smaller keys or removing keys other than the last one
+// invalidates this.
+func (s *sparse[K]) SortedByKey() bool { return s.sortedByKey }
+
+func (s *sparse[K]) position(key K) (int, bool) {
+	if !s.inRange(key) {
+		return 0, false
+	}
+
+	// Fast path for the default width, which is inlined (see
+	// PagedArrayOf.getLinear()).
+	var pos int
+	var ok bool
+	if index := s.index.index; index != nil {
+		pos, ok = index.getLinear(key)
+	}
+	if !ok {
+		pos = s.index.Get(key)
+	}
+
+	if pos == s.index.nullValue {
+		return 0, false
+	}
+
+	return pos, true
+}
+
+// add inserts the key at the end of the dense array if it is not already
+// there. Returns the position of the key, whether the key was inserted, and
+// whether the key is valid.
+func (s *sparse[K]) add(key K) (int, bool, bool) {
+	if !s.inRange(key) {
+		return 0, false, false
+	}
+
+	pos := s.index.Get(key)
+	if pos != s.index.NullValue() {
+		return pos, false, true
+	}
+
+	pos = len(s.dense)
+	if pos > 0 && key < s.dense[pos-1] {
+		s.sortedByKey = false
+	}
+
+	s.dense = append(s.dense, key)
+	s.index.Set(key, pos)
+	s.notifyAdded(key)
+	return pos, true, true
+}
+
+// remove deletes the key by moving the key in the last position of the dense
+// array into the position of the removed key. Returns the position of the
+// removed key, the last position, and whether the key was in the set. The
+// caller must move its values in the same way.
+func (s *sparse[K]) remove(key K) (int, int, bool) {
+	pos, ok := s.position(key)
+	if !ok {
+		return 0, 0, false
+	}
+
+	last := len(s.dense) - 1
+
+	s.index.Unset(key)
+	if pos != last {
+		s.index.Set(s.dense[last], pos)
+		s.sortedByKey = false
+	}
+
+	s.dense[pos], s.dense[last] = s.dense[last], s.nullKey
+	s.dense = s.dense[:last]
+
+	s.notifyRemoved(key)
+	return pos, last, true
+}
+
+func (s *sparse[K]) clear() {
+	s.index.Clear()
+	s.dense = s.dense[:0]
+	s.sortedByKey = true
+
+	for _, observer := range s.observers {
+		observer.keysCleared()
+	}
+}
+
+func (s *sparse[K]) clone() sparse[K] {
+	clone := *s
+	clone.index = s.index.clone()
+	clone.dense = slices.Clone(s.dense)
+	clone.observers = nil
+	return clone
+}
+
+// empty returns an empty sparse with the same configuration.
+func (s *sparse[K]) empty() sparse[K] {
+	keys := newSparse(s.index.empty(), s.nullKey)
+	keys.growable = s.growable
+	return keys
+}
+
+func (s *sparse[K]) compact() { s.index.Compact() }
+
+func (s *sparse[K]) shrinkToFit() {
+	s.index.ShrinkToFit()
+	s.dense = shrink(s.dense)
+}
+
+// nullPosition returns the null value of the index of a set whose keys are
+// less than nullKey. Since a set has at most nullKey elements, no position is
+// greater than or equal to nullKey.
+func nullPosition[K Key](nullKey K) int {
+	if nullKey < 0 {
+		return 0
+	}
+	return int(min(uint64(nullKey), math.MaxInt))
+}
+
+// maxKey returns the largest value of type K.
+func maxKey[K Key]() K {
+	key := ^K(0)
+	if key > 0 {
+		// K is unsigned.
+		return key
+	}
+
+	return K(uint64(1)<<(8*unsafe.Sizeof(key)-1) - 1)
+}
+
+func newSparse[K Key](index *positionIndex[K], nullKey K) sparse[K] {
+	return sparse[K]{
+		index,
+		[]K{},
+		nullKey,
+		false, /* growable */
+		true,  /* sortedByKey */
+		nil,   /* observers */
+	}
+}
@@ -0,0 +1,231 @@
+package sparseset
+
+// tombstoneKey marks a slot in a stableChunk that does not hold a value.
+const tombstoneKey = -1
+
+type stableChunk[Value any] struct {
+	// Stores keys by offset. Empty slots are marked with tombstoneKey.
+	keys []int
+	// Stores values by offset. This slice is never reallocated.
+	values []Value
+	// Number of slots in this chunk that hold a value.
+	numValues int
+}
+
+// StableSet is a sparse set whose values never move in memory.
+//
+// Values are stored in fixed-size chunks that are never reallocated, therefore
+// the pointers returned by Add() and Get() remain valid until the key is
+// removed from the set. Removing a key leaves a tombstone in its slot, which is
+// reused by a subsequent Add().
+//
+// Iteration traverses the chunks linearly, skipping tombstones, so it is still
+// cache friendly but it is slightly slower than iterating a Set, especially
+// after many removals.
+//
+// This is thread-compatible (see thread-safety notes on Set).
+type StableSet[Value any] struct {
+	// Sparse (paged) array. Stores slots by key.
+	index *PagedArray[int]
+	// Stores keys and values by slot.
+	chunks []*stableChunk[Value]
+	// Number of slots per chunk.
+	chunkSize int
+	// Number of slots that were ever handed out.
+	numSlots int
+	// Slots that were released by Remove() and can be reused by Add().
+	free []int
+	// Destroy (or uninitializes) values when they are removed from the store.
+	destroyValue func(*Value)
+}
+
+func (s *StableSet[Value]) Length() int { return s.index.Length() }
+
+// inRange returns true if the key is valid for this set.
+func (s *StableSet[Value]) inRange(key int) bool {
+	return key >= 0 && key < s.index.NullValue()
+}
+
+func (s *StableSet[Value]) slot(slot int) (*stableChunk[Value], int) {
+	return s.chunks[slot/s.chunkSize], slot % s.chunkSize
+}
+
+// Add inserts key into the set and returns a pointer to its value. If the key
+// is already in the set, it returns a pointer to the existing value. The
+// pointer remains valid until the key is removed. Returns nil if the key is out
+// of range, or panics in debug builds (see TryAdd()).
+func (s *StableSet[Value]) Add(key int) *Value {
+	if !s.inRange(key) {
+		if debug {
+			panic(keyError(ErrKeyOutOfRange, key))
+		}
+		return nil
+	}
+
+	if slot := s.index.Get(key); slot != s.index.NullValue() {
+		chunk, offset := s.slot(slot)
+		return &chunk.values[offset]
+	}
+
+	var slot int
+	if last := len(s.free) - 1; last >= 0 {
+		slot = s.free[last]
+		s.free = s.free[:last]
+	} else {
+		slot = s.numSlots
+		s.numSlots++
+
+		if slot/s.chunkSize >= len(s.chunks) {
+			chunk := &stableChunk[Value]{
+				make([]int, s.chunkSize),
+				make([]Value, s.chunkSize),
+				0, /* numValues */
+			}
+			for i := range chunk.keys {
+				chunk.keys[i] = tombstoneKey
+			}
+			s.chunks = append(s.chunks, chunk)
+		}
+	}
+
+	chunk, offset := s.slot(slot)
+	chunk.keys[offset] = key
+	chunk.numValues++
+
+	s.index.Set(key, slot)
+	return &chunk.values[offset]
+}
+
+// Remove deletes key from the set. The values of the other keys are not moved.
+// Does nothing if the key is not in the set. If the key is out of range, this
+// does nothing, or panics in debug builds (see TryRemove()).
+func (s *StableSet[Value]) Remove(key int) {
+	if !s.inRange(key) {
+		if debug {
+			panic(keyError(ErrKeyOutOfRange, key))
+		}
+		return
+	}
+
+	slot := s.index.Get(key)
+	if slot == s.index.NullValue() {
+		return
+	}
+
+	s.index.Unset(key)
+
+	chunk, offset := s.slot(slot)
+
+	// Destroy store value.
+	s.destroyValue(&chunk.values[offset])
+
+	// Facilitate GC.
+	var defaultValue Value
+	chunk.values[offset] = defaultValue
+
+	chunk.keys[offset] = tombstoneKey
+	chunk.numValues--
+
+	s.free = append(s.free, slot)
+}
+
+// TryAdd is like Add but returns ErrKeyOutOfRange if the key is out of range.
+func (s *StableSet[Value]) TryAdd(key int) (*Value, error) {
+	if !s.inRange(key) {
+		return nil, keyError(ErrKeyOutOfRange, key)
+	}
+	return s.Add(key), nil
+}
+
+// TryRemove is like Remove but returns ErrKeyOutOfRange if the key is out of
+// range and ErrKeyNotFound if the key is not in the set.
+func (s *StableSet[Value]) TryRemove(key int) error {
+	if !s.inRange(key) {
+		return keyError(ErrKeyOutOfRange, key)
+	}
+
+	if _, ok := s.Get(key); !ok {
+		return keyError(ErrKeyNotFound, key)
+	}
+
+	s.Remove(key)
+	return nil
+}
+
+func (s *StableSet[Value]) Get(key int) (*Value, bool) {
+	if !s.inRange(key) {
+		return nil, false
+	}
+
+	slot := s.index.Get(key)
+	if slot == s.index.NullValue() {
+		return nil, false
+	}
+
+	chunk, offset := s.slot(slot)
+	return &chunk.values[offset], true
+}
+
+// IterateStable returns an iterator that can be used to traverse all the keys
+// and values of the set. The iteration order is the order of the slots, which
+// is not necessarily the insertion order because slots are reused.
+func IterateStable[A any](set *StableSet[A]) *Iterator[A] {
+	chunks := set.chunks
+	chunkNum := 0
+	offset := 0
+	remaining := set.Length()
+	get := func(int) (int, *A, bool) {
+		for ; chunkNum < len(chunks); chunkNum, offset = chunkNum+1, 0 {
+			chunk := chunks[chunkNum]
+			if chunk.numValues == 0 {
+				continue
+			}
+
+			for offset < len(chunk.keys) {
+				key, value := chunk.keys[offset], &chunk.values[offset]
+				offset++
+
+				if key != tombstoneKey {
+					remaining--
+					return key, value, true
+				}
+			}
+		}
+		return 0, nil, false
+	}
+
+	hint := func() (int, int) { return remaining, remaining }
+
+	return newIterator(get, hint)
+}
+
+// NewStable returns a stable set whose values are stored in chunks of chunkSize
+// values. A chunkSize less than 1 is treated as 1.
+func NewStable[Value any](defaultPageSize, nullKey, chunkSize int) *StableSet[Value] {
+	return NewStableWithOptions[Value](defaultPageSize, nullKey, chunkSize, Options[Value]{})
+}
+
+// NewStableWithOptions is like NewStable but accepts options. The
+// ShrinkThreshold option is ignored because the values of a StableSet never
+// move.
+func NewStableWithOptions[Value any](defaultPageSize, nullKey, chunkSize int, options Options[Value]) *StableSet[Value] {
+	if options.DestroyValue == nil {
+		options.DestroyValue = func(*Value) {}
+	}
+
+	return &StableSet[Value]{
+		newStableIndex(defaultPageSize, nullKey, options.PageAllocator),
+		nil, /* chunks */
+		max(chunkSize, 1),
+		0,   /* numSlots */
+		nil, /* free */
+		options.DestroyValue,
+	}
+}
+
+func newStableIndex(defaultPageSize, nullKey int, allocator PageAllocator[int]) *PagedArray[int] {
+	if allocator != nil {
+		return NewPagedArrayWithAllocator(allocator, nullKey)
+	}
+	return NewPagedArray(defaultPageSize, nullKey)
+}
@@ -0,0 +1,193 @@
+package sparseset_test
+
+import (
+	"cmp"
+	"errors"
+	"testing"
+
+	"github.com/jabolopes/go-sparseset"
+	"golang.org/x/exp/slices"
+)
+
+func TestStableSet_PointersAreStable(t *testing.T) {
+	const n = 1000
+
+	set := sparseset.NewStable[MyValue](1<<10, 1<<20, 64)
+
+	pointers := make([]*MyValue, n)
+	for i := 0; i < n; i++ {
+		pointers[i] = set.Add(i)
+		pointers[i].value = i
+	}
+
+	for i := 0; i < n; i += 2 {
+		set.Remove(i)
+	}
+
+	// Reuse the slots released by Remove().
+	for i := n; i < n+n/2; i++ {
+		set.Add(i).value = i
+	}
+
+	if got, want := set.Length(), n; got != want {
+		t.Errorf("Length() = %d; want %d", got, want)
+	}
+
+	for i := 1; i < n; i += 2 {
+		got, ok := set.Get(i)
+		if got != pointers[i] || !ok {
+			t.Errorf("Get(%d) = %p, %v; want %p, %v", i, got, ok, pointers[i], true)
+		}
+
+		if got.value != i {
+			t.Errorf("Get(%d).value = %d; want %d", i, got.value, i)
+		}
+	}
+
+	for i := 0; i < n; i += 2 {
+		if got, ok := set.Get(i); got != nil || ok {
+			t.Errorf("Get(%d) = %v, %v; want %v, %v", i, got, ok, nil, false)
+		}
+	}
+}
+
+func TestStableSet_Add(t *testing.T) {
+	set := sparseset.NewStable[MyValue](1<<10, 1<<20, 4)
+
+	value := set.Add(10)
+	if value == nil {
+		t.Fatalf("Add(10) = %v; want non-%v", value, nil)
+	}
+
+	if got := set.Add(10); got != value {
+		t.Errorf("Add(10) = %p; want %p", got, value)
+	}
+
+	if got := set.Length(); got != 1 {
+		t.Errorf("Length() = %d; want %d", got, 1)
+	}
+
+	if !debugBuild {
+		// Invalid keys panic in debug builds (see TestDebug_Panics).
+		for _, key := range []int{-1, 1 << 20} {
+			if got := set.Add(key); got != nil {
+				t.Errorf("Add(%d) = %v; want %v", key, got, nil)
+			}
+		}
+	}
+}
+
+func TestStableSet_TryAdd(t *testing.T) {
+	set := sparseset.NewStable[MyValue](1<<10, 1<<20, 4)
+
+	if value, err := set.TryAdd(10); value == nil || err != nil {
+		t.Errorf("TryAdd(%d) = %v, %v; want non-%v, %v", 10, value, err, nil, nil)
+	}
+
+	for _, key := range []int{-1, 1 << 20} {
+		if value, err := set.TryAdd(key); value != nil || !errors.Is(err, sparseset.ErrKeyOutOfRange) {
+			t.Errorf("TryAdd(%d) = %v, %v; want %v, %v", key, value, err, nil, sparseset.ErrKeyOutOfRange)
+		}
+	}
+
+	if got := set.Length(); got != 1 {
+		t.Errorf("Length() = %d; want %d", got, 1)
+	}
+}
+
+func TestStableSet_TryRemove(t *testing.T) {
+	set := sparseset.NewStable[MyValue](1<<10, 1<<20, 4)
+	set.Add(10)
+
+	tests := []struct {
+		key  int
+		want error
+	}{
+		{10, nil},
+		{10, sparseset.ErrKeyNotFound},
+		{-1, sparseset.ErrKeyOutOfRange},
+		{1 << 20, sparseset.ErrKeyOutOfRange},
+	}
+
+	for _, test := range tests {
+		if err := set.TryRemove(test.key); !errors.Is(err, test.want) {
+			t.Errorf("TryRemove(%d) = %v; want %v", test.key, err, test.want)
+		}
+	}
+
+	if got := set.Length(); got != 0 {
+		t.Errorf("Length() = %d; want %d", got, 0)
+	}
+}
+
+func TestStableSet_InvalidChunkSize(t *testing.T) {
+	for _, chunkSize := range []int{0, -1} {
+		set := sparseset.NewStable[MyValue](1<<10, 1<<20, chunkSize)
+		for i := 0; i < 3; i++ {
+			set.Add(i).value = i
+		}
+
+		if got := set.Length(); got != 3 {
+			t.Errorf("chunkSize %d: Length() = %d; want %d", chunkSize, got, 3)
+		}
+	}
+}
+
+func TestStableSet_Remove(t *testing.T) {
+	called := 0
+	options := sparseset.Options[MyValue]{DestroyValue: func(value *MyValue) {
+		called++
+	}}
+	set := sparseset.NewStableWithOptions[MyValue](1<<10, 1<<20, 4, options)
+
+	set.Add(10)
+	set.Remove(10)
+	set.Remove(10)
+
+	if got, ok := set.Get(10); got != nil || ok {
+		t.Errorf("Get(10) = %v, %v; want %v, %v", got, ok, nil, false)
+	}
+
+	if got := set.Length(); got != 0 {
+		t.Errorf("Length() = %d; want %d", got, 0)
+	}
+
+	if called != 1 {
+		t.Errorf("called = %d; want %d", called, 1)
+	}
+}
+
+func TestIterateStable(t *testing.T) {
+	const n = 100
+
+	set := sparseset.NewStable[MyValue](1<<10, 1<<20, 8)
+	for i := 0; i < n; i++ {
+		set.Add(i).value = i
+	}
+
+	want := []iterateResult[MyValue]{}
+	for i := 0; i < n; i++ {
+		// Empty some chunks entirely and leave tombstones in others.
+		if (i >= 16 && i < 32) || i%3 == 0 {
+			set.Remove(i)
+			continue
+		}
+		want = append(want, iterateResult[MyValue]{i, MyValue{i}, true})
+	}
+
+	got := iterateAll(sparseset.IterateStable(set))
+	slices.SortFunc(got, func(r1, r2 iterateResult[MyValue]) int { return cmp.Compare(r1.key, r2.key) })
+
+	if !slices.Equal(got, want) {
+		t.Errorf("results = %v; want %v", got, want)
+	}
+}
+
+func TestIterateStable_EmptySet(t *testing.T) {
+	set := sparseset.NewStable[string](4096, 1<<20, 8)
+
+	want := []iterateResult[string]{}
+	if got := iterateAll(sparseset.IterateStable(set)); !slices.Equal(got, want) {
+		t.Errorf("results = %v; want %v", got, want)
+	}
+}
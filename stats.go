@@ -0,0 +1,121 @@
+package sparseset
+
+import (
+	"unsafe"
+)
+
+// NumFillRatioBuckets is the number of buckets of the fill ratio distribution
+// in PagedArrayStats.
+const NumFillRatioBuckets = 10
+
+// PagedArrayStats describes the memory usage of a PagedArray.
+type PagedArrayStats struct {
+	// Number of values per page.
+	PageSize int
+	// Number of pages in the array, including empty pages.
+	Pages int
+	// Number of nodes of the radix directory (see
+	// PagedArrayOptions.MaxLinearPages).
+	DirectoryNodes int
+	// Number of pages that hold at least one value.
+	LivePages int
+	// Distribution of the fill ratio of the live pages. FillRatios[i] is the
+	// number of live pages whose fill ratio is in the interval (i/N, (i+1)/N],
+	// where N is NumFillRatioBuckets.
+	FillRatios [NumFillRatioBuckets]int
+	// Number of bytes used by the pages, including empty pages, and by the
+	// radix directory.
+	Bytes int
+	// Number of pages obtained from the allocator that were reused (hits) or
+	// newly allocated (misses).
+	PoolHits   int
+	PoolMisses int
+}
+
+// SetStats describes the memory usage of a Set.
+type SetStats struct {
+	// Number of keys in the set.
+	Length int
+	// Statistics of the index that maps keys to positions.
+	Index PagedArrayStats
+	// Number of bytes used by the dense array of keys and by the value store,
+	// including their excess capacity.
+	DenseBytes int
+	StoreBytes int
+	// Excess capacity of the value store, in number of values.
+	SlackCapacity int
+	// Number of bytes used by the excess capacity of the dense array and the
+	// value store.
+	SlackBytes int
+}
+
+// Bytes returns the total number of bytes used by the set.
+func (s SetStats) Bytes() int {
+	return s.Index.Bytes + s.DenseBytes + s.StoreBytes
+}
+
+// Stats returns the memory usage of the array. This traverses all pages of the
+// array.
+func (a *PagedArrayOf[K, Value]) Stats() PagedArrayStats {
+	var page page2[Value]
+	var value Value
+
+	stats := PagedArrayStats{
+		PageSize:   a.pageSize,
+		Pages:      len(a.pages),
+		Bytes:      cap(a.pages) * int(unsafe.Sizeof(page)),
+		PoolHits:   a.poolHits,
+		PoolMisses: a.poolMisses,
+	}
+
+	addPage := func(page *page2[Value]) {
+		if page.values == nil {
+			return
+		}
+
+		stats.Bytes += cap(page.values)*int(unsafe.Sizeof(value)) + cap(page.present)*8
+
+		if page.numValues > 0 {
+			stats.LivePages++
+			stats.FillRatios[(page.numValues*NumFillRatioBuckets-1)/a.pageSize]++
+		}
+	}
+
+	for i := range a.pages {
+		addPage(&a.pages[i])
+	}
+
+	var node *radixNode[Value]
+	directory := &a.directory
+	stats.DirectoryNodes = directory.numInterior + directory.numLeaves
+	stats.Bytes += directory.numInterior * radixFanout * int(unsafe.Sizeof(node))
+	stats.Bytes += directory.numLeaves * radixFanout * int(unsafe.Sizeof(page))
+	directory.forEach(func(_ uint64, page *page2[Value]) {
+		stats.Pages++
+		addPage(page)
+	})
+
+	return stats
+}
+
+// Stats returns the memory usage of the set. This traverses all pages of the
+// index.
+func (s *SetOf[K, Value]) Stats() SetStats {
+	var key K
+	var value Value
+
+	keySize := int(unsafe.Sizeof(key))
+	valueSize := int(unsafe.Sizeof(value))
+
+	denseSlack := cap(s.dense) - len(s.dense)
+	storeSlack := cap(s.store) - len(s.store)
+
+	return SetStats{
+		Length:        s.Length(),
+		Index:         s.index.Stats(),
+		DenseBytes:    cap(s.dense) * keySize,
+		StoreBytes:    cap(s.store) * valueSize,
+		SlackCapacity: storeSlack,
+		SlackBytes:    denseSlack*keySize + storeSlack*valueSize,
+	}
+}
@@ -0,0 +1,82 @@
+package sparseset_test
+
+import (
+	"testing"
+
+	"github.com/jabolopes/go-sparseset"
+)
+
+func TestPagedArrayStats(t *testing.T) {
+	const pageSize = 10
+	const nullValue = 1000
+	array := sparseset.NewPagedArray(pageSize, nullValue)
+
+	// Page 0 is full, page 1 is empty, page 2 has 1 value, and page 3 has 5
+	// values.
+	for i := 0; i < 10; i++ {
+		array.Set(i, i)
+	}
+	array.Set(10, 10)
+	array.Unset(10)
+	array.Set(20, 20)
+	for i := 30; i < 35; i++ {
+		array.Set(i, i)
+	}
+
+	got := array.Stats()
+
+	if got.PageSize != pageSize || got.Pages != 4 || got.LivePages != 3 {
+		t.Errorf("Stats() = %+v; want PageSize %d, Pages %d, LivePages %d", got, pageSize, 4, 3)
+	}
+
+	want := [sparseset.NumFillRatioBuckets]int{}
+	want[0] = 1
+	want[4] = 1
+	want[9] = 1
+	if got.FillRatios != want {
+		t.Errorf("FillRatios = %v; want %v", got.FillRatios, want)
+	}
+
+	if got.PoolHits+got.PoolMisses != 4 || got.PoolMisses < 3 {
+		t.Errorf("PoolHits, PoolMisses = %d, %d; want 4 pool gets with at least 3 misses", got.PoolHits, got.PoolMisses)
+	}
+
+	if wantMin := 3 * pageSize * 8; got.Bytes < wantMin {
+		t.Errorf("Bytes = %d; want >= %d", got.Bytes, wantMin)
+	}
+}
+
+func TestSetStats(t *testing.T) {
+	set := sparseset.New[[4]int64](1<<10, 1<<20)
+	for i := 0; i < 100; i++ {
+		set.Add(i)
+	}
+	set.ShrinkToFit()
+	set.Remove(0)
+
+	got := set.Stats()
+
+	if got.Length != 99 {
+		t.Errorf("Length = %d; want %d", got.Length, 99)
+	}
+
+	if got.Index.LivePages != 1 {
+		t.Errorf("Index.LivePages = %d; want %d", got.Index.LivePages, 1)
+	}
+
+	if want := 100 * 8; got.DenseBytes != want {
+		t.Errorf("DenseBytes = %d; want %d", got.DenseBytes, want)
+	}
+
+	if want := 100 * 32; got.StoreBytes != want {
+		t.Errorf("StoreBytes = %d; want %d", got.StoreBytes, want)
+	}
+
+	if got.SlackCapacity != 1 || got.SlackBytes != 8+32 {
+		t.Errorf("SlackCapacity, SlackBytes = %d, %d; want %d, %d", got.SlackCapacity, got.SlackBytes, 1, 8+32)
+	}
+
+	if want := got.Index.Bytes + got.DenseBytes + got.StoreBytes; got.Bytes() != want {
+		t.Errorf("Bytes() = %d; want %d", got.Bytes(), want)
+	}
+}
@@ -0,0 +1,130 @@
+package sparseset
+
+// MapSet returns a set with the keys of src for which f returns true and the
+// values returned by f. The returned set has the same page size and null key as
+// src but no options.
+func MapSet[A, B any, K Key](src *SetOf[K, A], f func(K, *A) (B, bool)) *SetOf[K, B] {
+	dst := &SetOf[K, B]{
+		src.sparse.empty(),
+		[]B{},
+		func(*B) {}, /* destroyValue */
+		0,           /* shrinkThreshold */
+	}
+	MapSetInto(dst, src, f)
+	return dst
+}
+
+// MapSetInto is like MapSet but clears dst (see Clear()) and stores the keys and
+// values in dst, reusing its capacity. The keys that are out of the range of dst
+// are ignored. dst must not be src.
+func MapSetInto[A, B any, K Key](dst *SetOf[K, B], src *SetOf[K, A], f func(K, *A) (B, bool)) {
+	dst.Clear()
+
+	for pos, key := range src.dense {
+		value, ok := f(key, &src.store[pos])
+		if !ok {
+			continue
+		}
+
+		if b, err := dst.TryAdd(key); err == nil {
+			*b = value
+		}
+	}
+}
+
+// FilterSet returns a set with the keys and values of src for which pred
+// returns true. The values are copied by assignment (i.e., a shallow copy). The
+// returned set has the same page size, null key and options as src.
+func FilterSet[A any, K Key](src *SetOf[K, A], pred func(K, *A) bool) *SetOf[K, A] {
+	dst := &SetOf[K, A]{
+		src.sparse.empty(),
+		[]A{},
+		src.destroyValue,
+		src.shrinkThreshold,
+	}
+	FilterSetInto(dst, src, pred)
+	return dst
+}
+
+// FilterSetInto is like FilterSet but clears dst (see Clear()) and stores the
+// keys and values in dst, reusing its capacity. The keys that are out of the
+// range of dst are ignored. dst must not be src (see RemoveIf()).
+func FilterSetInto[A any, K Key](dst, src *SetOf[K, A], pred func(K, *A) bool) {
+	MapSetInto(dst, src, func(key K, value *A) (A, bool) {
+		if !pred(key, value) {
+			var defaultValue A
+			return defaultValue, false
+		}
+		return *value, true
+	})
+}
+
+// Reduce calls f with the accumulated result, initially init, and each key and
+// value of the set, in the order of the set, and returns the final result.
+func Reduce[A, R any, K Key](set *SetOf[K, A], init R, f func(R, K, *A) R) R {
+	result := init
+	for pos, key := range set.dense {
+		result = f(result, key, &set.store[pos])
+	}
+	return result
+}
+
+// Count returns the number of keys of the set for which pred returns true.
+func Count[A any, K Key](set *SetOf[K, A], pred func(K, *A) bool) int {
+	n := 0
+	for pos, key := range set.dense {
+		if pred(key, &set.store[pos]) {
+			n++
+		}
+	}
+	return n
+}
+
+// Any returns true if pred returns true for some key of the set. This stops at
+// the first such key.
+func Any[A any, K Key](set *SetOf[K, A], pred func(K, *A) bool) bool {
+	for pos, key := range set.dense {
+		if pred(key, &set.store[pos]) {
+			return true
+		}
+	}
+	return false
+}
+
+// All returns true if pred returns true for all keys of the set, including if
+// the set is empty. This stops at the first key for which pred returns false.
+func All[A any, K Key](set *SetOf[K, A], pred func(K, *A) bool) bool {
+	for pos, key := range set.dense {
+		if !pred(key, &set.store[pos]) {
+			return false
+		}
+	}
+	return true
+}
+
+// GroupBy returns the keys of the set grouped by the result of keyFn. The keys
+// of each group are in the order of the set.
+func GroupBy[A any, G comparable, K Key](set *SetOf[K, A], keyFn func(K, *A) G) map[G][]K {
+	groups := map[G][]K{}
+	GroupByInto(groups, set, keyFn)
+	return groups
+}
+
+// GroupByInto is like GroupBy but stores the groups in dst, reusing the
+// capacity of its groups. The groups of dst that become empty are deleted.
+func GroupByInto[A any, G comparable, K Key](dst map[G][]K, set *SetOf[K, A], keyFn func(K, *A) G) {
+	for group, keys := range dst {
+		dst[group] = keys[:0]
+	}
+
+	for pos, key := range set.dense {
+		group := keyFn(key, &set.store[pos])
+		dst[group] = append(dst[group], key)
+	}
+
+	for group, keys := range dst {
+		if len(keys) == 0 {
+			delete(dst, group)
+		}
+	}
+}
@@ -0,0 +1,141 @@
+package sparseset_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/jabolopes/go-sparseset"
+	"golang.org/x/exp/maps"
+	"golang.org/x/exp/slices"
+)
+
+func TestMapSet(t *testing.T) {
+	src := newSetFrom(5, 1, 4, 2)
+
+	dst := sparseset.MapSet(src, func(key int, value *MyValue) (string, bool) {
+		return string(rune('a' + value.value)), key%2 == 0
+	})
+
+	if got, want := dst.Keys(), []int{4, 2}; !slices.Equal(got, want) {
+		t.Errorf("Keys() = %v; want %v", got, want)
+	}
+
+	if got, want := dst.Values(), []string{"e", "c"}; !slices.Equal(got, want) {
+		t.Errorf("Values() = %v; want %v", got, want)
+	}
+
+	// The keys out of range of src are out of range of dst.
+	if got, err := dst.TryAdd(1 << 20); got != nil || !errors.Is(err, sparseset.ErrKeyOutOfRange) {
+		t.Errorf("TryAdd(%d) = %v, %v; want %v, %v", 1<<20, got, err, nil, sparseset.ErrKeyOutOfRange)
+	}
+}
+
+func TestMapSetInto(t *testing.T) {
+	destroyed := 0
+	options := sparseset.Options[int]{DestroyValue: func(*int) { destroyed++ }}
+	dst := sparseset.NewWithOptions[int](1<<10, 1<<20, options)
+	*dst.Add(100) = 100
+
+	src := newSetFrom(1, 2, 3)
+	sparseset.MapSetInto(dst, src, func(key int, value *MyValue) (int, bool) {
+		return value.value * 10, true
+	})
+
+	if destroyed != 1 {
+		t.Errorf("destroyed = %d; want %d", destroyed, 1)
+	}
+
+	if got, want := dst.Keys(), []int{1, 2, 3}; !slices.Equal(got, want) {
+		t.Errorf("Keys() = %v; want %v", got, want)
+	}
+
+	if got, want := dst.Values(), []int{10, 20, 30}; !slices.Equal(got, want) {
+		t.Errorf("Values() = %v; want %v", got, want)
+	}
+}
+
+func TestFilterSet(t *testing.T) {
+	src := newSetFrom(5, 1, 4, 2)
+	isOdd := func(key int, _ *MyValue) bool { return key%2 == 1 }
+
+	if got, want := sparseset.FilterSet(src, isOdd), newSetFrom(5, 1); !sparseset.EqualOrdered(got, want, eqMyValue) {
+		t.Errorf("FilterSet() mismatch:\n%s", sparseset.DiffReport(got, want, eqMyValue))
+	}
+
+	dst := newSetFrom(7, 8)
+	sparseset.FilterSetInto(dst, src, isOdd)
+
+	if want := newSetFrom(5, 1); !sparseset.EqualOrdered(dst, want, eqMyValue) {
+		t.Errorf("FilterSetInto() mismatch:\n%s", sparseset.DiffReport(dst, want, eqMyValue))
+	}
+
+	// src is unchanged.
+	if want := newSetFrom(5, 1, 4, 2); !sparseset.EqualOrdered(src, want, eqMyValue) {
+		t.Errorf("src mismatch:\n%s", sparseset.DiffReport(src, want, eqMyValue))
+	}
+}
+
+func TestReduce(t *testing.T) {
+	set := newSetFrom(1, 2, 3, 4)
+
+	got := sparseset.Reduce(set, 0, func(sum, key int, value *MyValue) int { return sum + key*value.value })
+	if want := 1 + 4 + 9 + 16; got != want {
+		t.Errorf("Reduce() = %d; want %d", got, want)
+	}
+
+	keys := sparseset.Reduce(sparseset.New[MyValue](1<<10, 1<<20), []int{}, func(keys []int, key int, _ *MyValue) []int { return append(keys, key) })
+	if len(keys) != 0 {
+		t.Errorf("Reduce() = %v; want %v", keys, []int{})
+	}
+}
+
+func TestCountAnyAll(t *testing.T) {
+	set := newSetFrom(1, 2, 3, 4, 5)
+	isEven := func(key int, _ *MyValue) bool { return key%2 == 0 }
+	isPositive := func(key int, _ *MyValue) bool { return key > 0 }
+	isLarge := func(key int, _ *MyValue) bool { return key > 10 }
+
+	if got := sparseset.Count(set, isEven); got != 2 {
+		t.Errorf("Count(isEven) = %d; want %d", got, 2)
+	}
+
+	if got := sparseset.Any(set, isEven); !got {
+		t.Errorf("Any(isEven) = %v; want %v", got, true)
+	}
+
+	if got := sparseset.Any(set, isLarge); got {
+		t.Errorf("Any(isLarge) = %v; want %v", got, false)
+	}
+
+	if got := sparseset.All(set, isPositive); !got {
+		t.Errorf("All(isPositive) = %v; want %v", got, true)
+	}
+
+	if got := sparseset.All(set, isEven); got {
+		t.Errorf("All(isEven) = %v; want %v", got, false)
+	}
+
+	empty := sparseset.New[MyValue](1<<10, 1<<20)
+	if got := sparseset.All(empty, isLarge); !got {
+		t.Errorf("All(empty) = %v; want %v", got, true)
+	}
+}
+
+func TestGroupBy(t *testing.T) {
+	set := newSetFrom(5, 1, 4, 2, 3)
+	parity := func(key int, _ *MyValue) bool { return key%2 == 0 }
+
+	groups := sparseset.GroupBy(set, parity)
+
+	want := map[bool][]int{true: {4, 2}, false: {5, 1, 3}}
+	if !maps.EqualFunc(groups, want, slices.Equal[[]int]) {
+		t.Errorf("GroupBy() = %v; want %v", groups, want)
+	}
+
+	sparseset.GroupByInto(groups, newSetFrom(3, 7), parity)
+
+	want = map[bool][]int{false: {3, 7}}
+	if !maps.EqualFunc(groups, want, slices.Equal[[]int]) {
+		t.Errorf("GroupByInto() = %v; want %v", groups, want)
+	}
+}
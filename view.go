@@ -0,0 +1,162 @@
+package sparseset
+
+// view maintains the keys that are in all the given sets as keys are added to
+// or removed from those sets. It is shared by the different view types, which
+// look up the values of the keys in their sets.
+type view[K Key] struct {
+	sets []*sparse[K]
+	// Keys that are in all the sets. The keys are not sorted.
+	matches sparse[K]
+}
+
+func (v *view[K]) keyAdded(key K) {
+	for _, set := range v.sets {
+		if !set.Has(key) {
+			return
+		}
+	}
+	v.matches.add(key)
+}
+
+func (v *view[K]) keyRemoved(key K) { v.matches.remove(key) }
+
+func (v *view[K]) keysCleared() { v.matches.clear() }
+
+func (v *view[K]) close() {
+	for _, set := range v.sets {
+		set.unobserve(v)
+	}
+	v.sets = nil
+	v.matches.clear()
+}
+
+// newView returns a view of the given sets, which is registered with the sets
+// and contains the keys that are already in all of them.
+func newView[K Key](sets ...*sparse[K]) *view[K] {
+	v := &view[K]{sets, sets[0].empty()}
+
+	smallest := sets[chooseDriver(sets, false /* adaptive */)]
+	for _, key := range smallest.dense {
+		v.keyAdded(key)
+	}
+
+	for _, set := range sets {
+		set.observe(v)
+	}
+	return v
+}
+
+// View is a ViewOf with int keys.
+type View[A, B any] = ViewOf[int, A, B]
+
+// ViewOf is a join of 2 sets that is maintained incrementally, i.e., the view
+// is registered with the sets and it updates the keys that are in all the sets
+// as keys are added to or removed from any of the sets. Therefore, iterating a
+// view costs O(matches) instead of O(smallest set) like Join(), at the expense
+// of a small cost on every Add() and Remove() of the sets.
+//
+// The keys of the view are not sorted and their order changes as keys are
+// removed, like the keys of a Set.
+//
+// A view must be closed with Close() when it is no longer needed, otherwise
+// the sets keep updating it (and keep it alive).
+//
+// This is thread-compatible (see thread-safety notes on Set).
+type ViewOf[K Key, A, B any] struct {
+	view *view[K]
+	set1 *SetOf[K, A]
+	set2 *SetOf[K, B]
+}
+
+// Length returns the number of keys that are in all the sets.
+func (v *ViewOf[K, A, B]) Length() int { return v.view.matches.Length() }
+
+// Has returns true if the key is in all the sets.
+func (v *ViewOf[K, A, B]) Has(key K) bool { return v.view.matches.Has(key) }
+
+// Keys returns the keys that are in all the sets. The slice is owned by the
+// view and it is modified when the sets are modified.
+func (v *ViewOf[K, A, B]) Keys() []K { return v.view.matches.Keys() }
+
+// Iterate returns an iterator over the keys of the view and their values in the
+// sets. The keys of the view are looked up in every set.
+func (v *ViewOf[K, A, B]) Iterate() *JoinIteratorOf[K, A, B] {
+	return &JoinIteratorOf[K, A, B]{set1: v.set1, set2: v.set2, keys: v.view.matches.dense, driven: true}
+}
+
+// Close unregisters the view from the sets. The view is empty afterwards.
+func (v *ViewOf[K, A, B]) Close() { v.view.close() }
+
+// NewView returns a view of the join of the given sets (see ViewOf).
+func NewView[A, B any, K Key](set1 *SetOf[K, A], set2 *SetOf[K, B]) *ViewOf[K, A, B] {
+	return &ViewOf[K, A, B]{newView(&set1.sparse, &set2.sparse), set1, set2}
+}
+
+// View3 is a View3Of with int keys.
+type View3[A, B, C any] = View3Of[int, A, B, C]
+
+// View3Of is a join of 3 sets that is maintained incrementally (see ViewOf).
+type View3Of[K Key, A, B, C any] struct {
+	view *view[K]
+	set1 *SetOf[K, A]
+	set2 *SetOf[K, B]
+	set3 *SetOf[K, C]
+}
+
+// Length returns the number of keys that are in all the sets.
+func (v *View3Of[K, A, B, C]) Length() int { return v.view.matches.Length() }
+
+// Has returns true if the key is in all the sets.
+func (v *View3Of[K, A, B, C]) Has(key K) bool { return v.view.matches.Has(key) }
+
+// Keys returns the keys that are in all the sets (see ViewOf.Keys()).
+func (v *View3Of[K, A, B, C]) Keys() []K { return v.view.matches.Keys() }
+
+// Iterate returns an iterator over the keys of the view and their values in the
+// sets (see ViewOf.Iterate()).
+func (v *View3Of[K, A, B, C]) Iterate() *Join3IteratorOf[K, A, B, C] {
+	return &Join3IteratorOf[K, A, B, C]{set1: v.set1, set2: v.set2, set3: v.set3, keys: v.view.matches.dense, driven: true}
+}
+
+// Close unregisters the view from the sets. The view is empty afterwards.
+func (v *View3Of[K, A, B, C]) Close() { v.view.close() }
+
+// NewView3 returns a view of the join of the given sets (see ViewOf).
+func NewView3[A, B, C any, K Key](set1 *SetOf[K, A], set2 *SetOf[K, B], set3 *SetOf[K, C]) *View3Of[K, A, B, C] {
+	return &View3Of[K, A, B, C]{newView(&set1.sparse, &set2.sparse, &set3.sparse), set1, set2, set3}
+}
+
+// View4 is a View4Of with int keys.
+type View4[A, B, C, D any] = View4Of[int, A, B, C, D]
+
+// View4Of is a join of 4 sets that is maintained incrementally (see ViewOf).
+type View4Of[K Key, A, B, C, D any] struct {
+	view *view[K]
+	set1 *SetOf[K, A]
+	set2 *SetOf[K, B]
+	set3 *SetOf[K, C]
+	set4 *SetOf[K, D]
+}
+
+// Length returns the number of keys that are in all the sets.
+func (v *View4Of[K, A, B, C, D]) Length() int { return v.view.matches.Length() }
+
+// Has returns true if the key is in all the sets.
+func (v *View4Of[K, A, B, C, D]) Has(key K) bool { return v.view.matches.Has(key) }
+
+// Keys returns the keys that are in all the sets (see ViewOf.Keys()).
+func (v *View4Of[K, A, B, C, D]) Keys() []K { return v.view.matches.Keys() }
+
+// Iterate returns an iterator over the keys of the view and their values in the
+// sets (see ViewOf.Iterate()).
+func (v *View4Of[K, A, B, C, D]) Iterate() *Join4IteratorOf[K, A, B, C, D] {
+	return &Join4IteratorOf[K, A, B, C, D]{set1: v.set1, set2: v.set2, set3: v.set3, set4: v.set4, keys: v.view.matches.dense, driven: true}
+}
+
+// Close unregisters the view from the sets. The view is empty afterwards.
+func (v *View4Of[K, A, B, C, D]) Close() { v.view.close() }
+
+// NewView4 returns a view of the join of the given sets (see ViewOf).
+func NewView4[A, B, C, D any, K Key](set1 *SetOf[K, A], set2 *SetOf[K, B], set3 *SetOf[K, C], set4 *SetOf[K, D]) *View4Of[K, A, B, C, D] {
+	return &View4Of[K, A, B, C, D]{newView(&set1.sparse, &set2.sparse, &set3.sparse, &set4.sparse), set1, set2, set3, set4}
+}
@@ -0,0 +1,201 @@
+package sparseset_test
+
+import (
+	"cmp"
+	"math/rand"
+	"testing"
+
+	"github.com/jabolopes/go-sparseset"
+	"golang.org/x/exp/slices"
+)
+
+func sortedJoinAll[A, B any](iterator *sparseset.JoinIterator[A, B]) []joinResult[A, B] {
+	results := joinAll(iterator)
+	slices.SortFunc(results, func(r1, r2 joinResult[A, B]) int { return cmp.Compare(r1.key, r2.key) })
+	return results
+}
+
+func TestView(t *testing.T) {
+	set1 := newUnsortedSet(10, 1)
+	set2 := newUnsortedSet(10, 2)
+
+	view := sparseset.NewView(set1, set2)
+	defer view.Close()
+
+	check := func(step string) {
+		t.Helper()
+
+		want := sortedJoinAll(sparseset.Join(set1, set2))
+		if got := sortedJoinAll(view.Iterate()); !slices.Equal(got, want) {
+			t.Errorf("%s: Iterate() = %v; want %v", step, got, want)
+		}
+
+		if got := view.Length(); got != len(want) {
+			t.Errorf("%s: Length() = %d; want %d", step, got, len(want))
+		}
+
+		for _, result := range want {
+			if !view.Has(result.key) {
+				t.Errorf("%s: Has(%d) = %v; want %v", step, result.key, false, true)
+			}
+		}
+	}
+
+	check("New")
+
+	*set1.Add(12) = 12
+	check("Add set1")
+
+	*set2.Add(24) = 12
+	check("Add set2")
+
+	set1.Remove(4)
+	check("Remove set1")
+
+	set2.Remove(0)
+	check("Remove set2")
+
+	sparseset.RemoveIf(set1, func(key int, _ *int) bool { return key%4 == 0 })
+	check("RemoveIf")
+
+	set1.AddRange(0, 30, nil)
+	check("AddRange")
+
+	set2.AddMany([]int{1, 3, 5}, nil)
+	check("AddMany")
+
+	set2.Clear()
+	check("Clear")
+
+	*set2.Add(7) = 7
+	check("Add after Clear")
+}
+
+func TestView_Rand(t *testing.T) {
+	const n = 100
+
+	set1 := sparseset.New[int](4096, 1<<20)
+	set2 := sparseset.New[int](4096, 1<<20)
+
+	view := sparseset.NewView(set1, set2)
+	defer view.Close()
+
+	for i := 0; i < 10*n; i++ {
+		set := set1
+		if rand.Intn(2) == 0 {
+			set = set2
+		}
+
+		key := rand.Intn(n)
+		if rand.Intn(3) == 0 {
+			set.Remove(key)
+		} else {
+			*set.Add(key) = i
+		}
+	}
+
+	want := sortedJoinAll(sparseset.Join(set1, set2))
+	if got := sortedJoinAll(view.Iterate()); !slices.Equal(got, want) {
+		t.Errorf("Iterate() = %v; want %v", got, want)
+	}
+}
+
+func TestView_Close(t *testing.T) {
+	set1 := newBenchmarkSet(10, 1)
+	set2 := newBenchmarkSet(10, 2)
+
+	view := sparseset.NewView(set1, set2)
+	if got, want := view.Length(), 5; got != want {
+		t.Errorf("Length() = %d; want %d", got, want)
+	}
+
+	view.Close()
+	*set1.Add(20) = 20
+	*set2.Add(40) = 20
+
+	if got, want := view.Length(), 0; got != want {
+		t.Errorf("Length() after Close() = %d; want %d", got, want)
+	}
+
+	if _, _, _, ok := view.Iterate().Next(); ok {
+		t.Errorf("Next() after Close() = _, _, _, %v; want _, _, _, %v", ok, false)
+	}
+}
+
+func TestView_Clone(t *testing.T) {
+	set1 := newBenchmarkSet(10, 1)
+	set2 := newBenchmarkSet(10, 2)
+
+	view := sparseset.NewView(set1, set2)
+	defer view.Close()
+
+	clone := sparseset.Clone(set1, nil)
+	clone.Remove(0)
+
+	if !view.Has(0) {
+		t.Errorf("Has(%d) after removing from a clone = %v; want %v", 0, false, true)
+	}
+}
+
+func TestView3(t *testing.T) {
+	set1 := newBenchmarkSet(30, 1)
+	set2 := newBenchmarkSet(30, 2)
+	set3 := newBenchmarkSet(30, 3)
+
+	view := sparseset.NewView3(set1, set2, set3)
+	defer view.Close()
+
+	set3.Remove(12)
+	*set1.Add(30) = 30
+
+	want := join3All(sparseset.Join3DrivenBy(1, set1, set2, set3))
+	got := join3All(view.Iterate())
+	slices.SortFunc(got, func(r1, r2 join3Result[int, int, int]) int { return cmp.Compare(r1.key, r2.key) })
+	if !slices.Equal(got, want) {
+		t.Errorf("Iterate() = %v; want %v", got, want)
+	}
+}
+
+func TestView4(t *testing.T) {
+	set1 := newBenchmarkSet(30, 1)
+	set2 := newBenchmarkSet(30, 2)
+	set3 := newBenchmarkSet(30, 3)
+	set4 := newBenchmarkSet(30, 4)
+
+	view := sparseset.NewView4(set1, set2, set3, set4)
+	defer view.Close()
+
+	if got, want := view.Keys(), []int{0, 12, 24}; !slices.Equal(got, want) {
+		t.Errorf("Keys() = %v; want %v", got, want)
+	}
+
+	set4.Remove(12)
+	if got, want := view.Keys(), []int{0, 24}; !slices.Equal(got, want) {
+		t.Errorf("Keys() = %v; want %v", got, want)
+	}
+
+	if got, want := len(join4All(view.Iterate())), 2; got != want {
+		t.Errorf("len(Iterate()) = %d; want %d", got, want)
+	}
+}
+
+func BenchmarkView(b *testing.B) {
+	set1 := newUnsortedSet(10000, 1)
+	set2 := newUnsortedSet(10000, 100)
+
+	view := sparseset.NewView(set1, set2)
+	defer view.Close()
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	sum := 0
+	for n := 0; n < b.N; n++ {
+		for iterator := view.Iterate(); ; {
+			_, a, b, ok := iterator.Next()
+			if !ok {
+				break
+			}
+			sum += *a + *b
+		}
+	}
+}